@@ -0,0 +1,166 @@
+package chop
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// IAMPrincipal represents the IAM principal that signed a request
+type IAMPrincipal struct {
+	AccessKey      string
+	AccountID      string
+	CallerID       string
+	PrincipalOrgID string
+	UserARN        string
+	UserID         string
+}
+
+// GetJWTClaims returns the JWT claims for the request if it was authorized by a JWT or Cognito user pool authorizer
+func GetJWTClaims(r *http.Request) (map[string]string, bool) {
+	switch e := GetEvent(r).(type) {
+	case *events.APIGatewayProxyRequest:
+		m, ok := e.RequestContext.Authorizer["claims"].(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+
+		claims := make(map[string]string, len(m))
+		for k, v := range m {
+			claims[k] = claimString(v)
+		}
+
+		return claims, true
+
+	case *events.APIGatewayV2HTTPRequest:
+		a := e.RequestContext.Authorizer
+		if a == nil || a.JWT == nil {
+			return nil, false
+		}
+
+		return a.JWT.Claims, true
+	}
+
+	return nil, false
+}
+
+// GetScopes returns the OAuth scopes for the request if it was authorized by a JWT or Cognito user pool authorizer
+// Scopes are read from the space separated scope claim if the event does not contain them directly
+func GetScopes(r *http.Request) ([]string, bool) {
+	if e, ok := GetAPIGatewayV2HTTPRequest(r); ok {
+		if a := e.RequestContext.Authorizer; a != nil && a.JWT != nil && a.JWT.Scopes != nil {
+			return a.JWT.Scopes, true
+		}
+	}
+
+	claims, ok := GetJWTClaims(r)
+	if !ok {
+		return nil, false
+	}
+
+	s, ok := claims["scope"]
+	if !ok {
+		return nil, false
+	}
+
+	return strings.Fields(s), true
+}
+
+// GetIAMPrincipal returns the IAM principal for the request if it was signed using IAM authorization
+func GetIAMPrincipal(r *http.Request) (IAMPrincipal, bool) {
+	switch e := GetEvent(r).(type) {
+	case *events.APIGatewayProxyRequest:
+		id := e.RequestContext.Identity
+		if id.AccessKey == "" && id.UserArn == "" {
+			return IAMPrincipal{}, false
+		}
+
+		return IAMPrincipal{
+			AccessKey: id.AccessKey,
+			AccountID: id.AccountID,
+			CallerID:  id.Caller,
+			UserARN:   id.UserArn,
+			UserID:    id.User,
+		}, true
+
+	case *events.APIGatewayV2HTTPRequest:
+		a := e.RequestContext.Authorizer
+		if a == nil || a.IAM == nil {
+			return IAMPrincipal{}, false
+		}
+
+		return IAMPrincipal{
+			AccessKey:      a.IAM.AccessKey,
+			AccountID:      a.IAM.AccountID,
+			CallerID:       a.IAM.CallerID,
+			PrincipalOrgID: a.IAM.PrincipalOrgID,
+			UserARN:        a.IAM.UserARN,
+			UserID:         a.IAM.UserID,
+		}, true
+
+	case *events.LambdaFunctionURLRequest:
+		a := e.RequestContext.Authorizer
+		if a == nil || a.IAM == nil {
+			return IAMPrincipal{}, false
+		}
+
+		return IAMPrincipal{
+			AccessKey: a.IAM.AccessKey,
+			AccountID: a.IAM.AccountID,
+			CallerID:  a.IAM.CallerID,
+			UserARN:   a.IAM.UserARN,
+			UserID:    a.IAM.UserID,
+		}, true
+
+	case *VPCLatticeRequestV2:
+		id := e.RequestContext.Identity
+		if id.Type != "AWS_IAM" {
+			return IAMPrincipal{}, false
+		}
+
+		return IAMPrincipal{
+			PrincipalOrgID: id.PrincipalOrgID,
+			UserARN:        id.Principal,
+		}, true
+	}
+
+	return IAMPrincipal{}, false
+}
+
+// GetLambdaAuthorizerContext returns the lambda authorizer context for the request if it was authorized by a lambda authorizer
+// For API gateway proxy events the context includes the principalId and integrationLatency values
+func GetLambdaAuthorizerContext(r *http.Request) (map[string]interface{}, bool) {
+	switch e := GetEvent(r).(type) {
+	case *events.APIGatewayProxyRequest:
+		m := e.RequestContext.Authorizer
+		if _, ok := m["claims"]; ok || len(m) == 0 {
+			return nil, false
+		}
+
+		return m, true
+
+	case *events.APIGatewayV2HTTPRequest:
+		a := e.RequestContext.Authorizer
+		if a == nil || a.Lambda == nil {
+			return nil, false
+		}
+
+		return a.Lambda, true
+	}
+
+	return nil, false
+}
+
+func claimString(v interface{}) string {
+	switch t := v.(type) {
+	case string:
+		return t
+	case float64:
+		return strconv.FormatFloat(t, 'f', -1, 64)
+	default:
+		return fmt.Sprint(t)
+	}
+}
@@ -0,0 +1,153 @@
+package chop_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stevecallear/chop/v2"
+)
+
+func TestAuthorizerAccessors(t *testing.T) {
+	type result struct {
+		claims   map[string]string
+		claimsOK bool
+		scopes   []string
+		scopesOK bool
+		iam      chop.IAMPrincipal
+		iamOK    bool
+		lambda   map[string]interface{}
+		lambdaOK bool
+	}
+
+	tests := []struct {
+		name    string
+		payload string
+		exp     result
+	}{
+		{
+			name:    "should return api gateway proxy cognito claims",
+			payload: `{"httpMethod":"GET","path":"/","requestContext":{"apiId":"id","authorizer":{"claims":{"sub":"user","scope":"read write","exp":1583348638}}}}`,
+			exp: result{
+				claims:   map[string]string{"sub": "user", "scope": "read write", "exp": "1583348638"},
+				claimsOK: true,
+				scopes:   []string{"read", "write"},
+				scopesOK: true,
+			},
+		},
+		{
+			name:    "should return api gateway proxy lambda authorizer context",
+			payload: `{"httpMethod":"GET","path":"/","requestContext":{"apiId":"id","authorizer":{"principalId":"user","tenant":"t1"}}}`,
+			exp: result{
+				lambda:   map[string]interface{}{"principalId": "user", "tenant": "t1"},
+				lambdaOK: true,
+			},
+		},
+		{
+			name:    "should return api gateway proxy iam principal",
+			payload: `{"httpMethod":"GET","path":"/","requestContext":{"apiId":"id","identity":{"accessKey":"key","accountId":"123","caller":"caller","user":"userid","userArn":"arn:aws:iam::123:user/u"}}}`,
+			exp: result{
+				iam: chop.IAMPrincipal{
+					AccessKey: "key",
+					AccountID: "123",
+					CallerID:  "caller",
+					UserARN:   "arn:aws:iam::123:user/u",
+					UserID:    "userid",
+				},
+				iamOK: true,
+			},
+		},
+		{
+			name:    "should return api gateway http v2 jwt claims and scopes",
+			payload: `{"version":"2.0","rawPath":"/","routeKey":"$default","requestContext":{"apiId":"id","http":{"method":"GET"},"authorizer":{"jwt":{"claims":{"sub":"user"},"scopes":["read"]}}}}`,
+			exp: result{
+				claims:   map[string]string{"sub": "user"},
+				claimsOK: true,
+				scopes:   []string{"read"},
+				scopesOK: true,
+			},
+		},
+		{
+			name:    "should return api gateway http v2 scope claim",
+			payload: `{"version":"2.0","rawPath":"/","routeKey":"$default","requestContext":{"apiId":"id","http":{"method":"GET"},"authorizer":{"jwt":{"claims":{"sub":"user","scope":"read write"}}}}}`,
+			exp: result{
+				claims:   map[string]string{"sub": "user", "scope": "read write"},
+				claimsOK: true,
+				scopes:   []string{"read", "write"},
+				scopesOK: true,
+			},
+		},
+		{
+			name:    "should return api gateway http v2 lambda authorizer context",
+			payload: `{"version":"2.0","rawPath":"/","routeKey":"$default","requestContext":{"apiId":"id","http":{"method":"GET"},"authorizer":{"lambda":{"tenant":"t1"}}}}`,
+			exp: result{
+				lambda:   map[string]interface{}{"tenant": "t1"},
+				lambdaOK: true,
+			},
+		},
+		{
+			name:    "should return api gateway http v2 iam principal",
+			payload: `{"version":"2.0","rawPath":"/","routeKey":"$default","requestContext":{"apiId":"id","http":{"method":"GET"},"authorizer":{"iam":{"accessKey":"key","accountId":"123","callerId":"caller","principalOrgId":"org","userArn":"arn","userId":"userid"}}}}`,
+			exp: result{
+				iam: chop.IAMPrincipal{
+					AccessKey:      "key",
+					AccountID:      "123",
+					CallerID:       "caller",
+					PrincipalOrgID: "org",
+					UserARN:        "arn",
+					UserID:         "userid",
+				},
+				iamOK: true,
+			},
+		},
+		{
+			name:    "should return lambda function url iam principal",
+			payload: `{"version":"2.0","rawPath":"/","requestContext":{"apiId":"urlid","domainName":"urlid.lambda-url.eu-west-1.on.aws","http":{"method":"GET"},"authorizer":{"iam":{"accessKey":"key","accountId":"123","callerId":"caller","userArn":"arn","userId":"userid"}}}}`,
+			exp: result{
+				iam: chop.IAMPrincipal{
+					AccessKey: "key",
+					AccountID: "123",
+					CallerID:  "caller",
+					UserARN:   "arn",
+					UserID:    "userid",
+				},
+				iamOK: true,
+			},
+		},
+		{
+			name:    "should return vpc lattice v2 iam principal",
+			payload: `{"version":"2.0","path":"/","method":"GET","requestContext":{"serviceNetworkArn":"arn","identity":{"type":"AWS_IAM","principal":"arn:aws:iam::123:role/r","principalOrgID":"org"}}}`,
+			exp: result{
+				iam: chop.IAMPrincipal{
+					PrincipalOrgID: "org",
+					UserARN:        "arn:aws:iam::123:role/r",
+				},
+				iamOK: true,
+			},
+		},
+		{
+			name:    "should return nothing for unauthorized requests",
+			payload: apiGatewayProxyEventPayload,
+		},
+		{
+			name:    "should return nothing for alb target group events",
+			payload: albTargetGroupSingleValueEventPayload,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var act result
+			h := chop.Wrap(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				act.claims, act.claimsOK = chop.GetJWTClaims(r)
+				act.scopes, act.scopesOK = chop.GetScopes(r)
+				act.iam, act.iamOK = chop.GetIAMPrincipal(r)
+				act.lambda, act.lambdaOK = chop.GetLambdaAuthorizerContext(r)
+			}))
+
+			_, err := h.Invoke(context.Background(), []byte(tt.payload))
+			assertErrorExists(t, err, false)
+			assertDeepEqual(t, act, tt.exp)
+		})
+	}
+}
@@ -0,0 +1,291 @@
+package chop
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/tidwall/gjson"
+)
+
+type (
+	// Authorizer represents an API gateway lambda authorizer handler
+	// The wrapped handler receives the authorized request, with 2xx status codes allowing the request,
+	// 401 status codes returning an unauthorized response and all other status codes denying the request
+	Authorizer struct {
+		handler *Handler
+	}
+
+	// AuthorizerResult represents the result of an authorizer request
+	AuthorizerResult struct {
+		// PrincipalID is the principal identifier returned in policy responses
+		PrincipalID string
+
+		// Context contains the values that are passed to the integration
+		Context map[string]interface{}
+
+		// Resources are the policy resources, defaulting to the method or route ARN
+		Resources []string
+
+		// UsageIdentifierKey is the usage plan API key returned in REST API policy responses
+		UsageIdentifierKey string
+	}
+
+	authorizerResultContextKey struct{}
+)
+
+const (
+	authorizerPolicyVersion = "2012-10-17"
+	authorizerPolicyAction  = "execute-api:Invoke"
+)
+
+// ErrUnauthorized indicates that the authorizer request is unauthorized
+// API gateway returns a 401 response when the authorizer fails with this error
+var ErrUnauthorized = errors.New("Unauthorized")
+
+// StartAuthorizer wraps and starts the specified HTTP handler as a lambda authorizer function handler
+func StartAuthorizer(h http.Handler, opts ...Option) {
+	a := WrapAuthorizer(h, opts...)
+	lambda.StartWithOptions(a, a.handler.lambdaOptions...)
+}
+
+// WrapAuthorizer wraps the specified HTTP handler as a lambda authorizer function handler
+// Authorizer responses are policies rather than HTTP responses, so the event processor, error handler,
+// binary policy and local options are ignored. Invalid events fail the lambda invocation.
+func WrapAuthorizer(h http.Handler, opts ...Option) *Authorizer {
+	return &Authorizer{
+		handler: Wrap(h, opts...),
+	}
+}
+
+// Invoke invokes the lambda authorizer function handler
+func (a *Authorizer) Invoke(ctx context.Context, payload []byte) ([]byte, error) {
+	res, err := a.invoke(ctx, payload)
+	if a.handler.recorder != nil {
+		a.handler.recorder.record(ctx, payload, res, err)
+	}
+
+	return res, err
+}
+
+func (a *Authorizer) invoke(ctx context.Context, payload []byte) ([]byte, error) {
+	pv := gjson.GetManyBytes(payload, "type", "version", "methodArn", "routeArn")
+
+	var r *http.Request
+	var err error
+	var arn string
+
+	switch {
+	case pv[0].String() == "TOKEN" && pv[2].Exists():
+		arn = pv[2].String()
+		r, err = unmarshalTokenAuthorizerRequest(ctx, payload)
+	case pv[0].String() == "REQUEST" && pv[1].String() != "2.0" && pv[2].Exists():
+		arn = pv[2].String()
+		r, err = unmarshalRequestAuthorizerRequest(ctx, payload)
+	case pv[0].String() == "REQUEST" && pv[1].String() == "2.0" && pv[3].Exists():
+		arn = pv[3].String()
+		r, err = unmarshalV2AuthorizerRequest(ctx, payload)
+	default:
+		return nil, ErrUnsupportedEventType
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, rw := range a.handler.pathRewriters {
+		rw(r)
+	}
+
+	res := &AuthorizerResult{
+		Context:   map[string]interface{}{},
+		Resources: []string{arn},
+	}
+
+	w := NewResponseWriter()
+	a.handler.serveHTTP(w, withAuthorizerResult(r, res))
+
+	code := w.StatusCode()
+	if code == http.StatusUnauthorized {
+		return nil, ErrUnauthorized
+	}
+
+	allow := code >= 200 && code < 300
+
+	if pv[1].String() == "2.0" && a.handler.simpleAuthorizerResponses {
+		return json.Marshal(&events.APIGatewayV2CustomAuthorizerSimpleResponse{
+			IsAuthorized: allow,
+			Context:      res.Context,
+		})
+	}
+
+	if pv[1].String() == "2.0" {
+		return json.Marshal(&events.APIGatewayV2CustomAuthorizerIAMPolicyResponse{
+			PrincipalID:    res.PrincipalID,
+			PolicyDocument: authorizerPolicy(allow, res.Resources),
+			Context:        res.Context,
+		})
+	}
+
+	return json.Marshal(&events.APIGatewayCustomAuthorizerResponse{
+		PrincipalID:        res.PrincipalID,
+		PolicyDocument:     authorizerPolicy(allow, res.Resources),
+		Context:            res.Context,
+		UsageIdentifierKey: res.UsageIdentifierKey,
+	})
+}
+
+// GetAuthorizerResult returns the authorizer result stored within the specified request context if it exists
+// The result is populated by the wrapped handler to configure the authorizer response
+func GetAuthorizerResult(r *http.Request) (*AuthorizerResult, bool) {
+	res, ok := r.Context().Value(authorizerResultContextKey{}).(*AuthorizerResult)
+	return res, ok
+}
+
+func withAuthorizerResult(r *http.Request, res *AuthorizerResult) *http.Request {
+	ctx := context.WithValue(r.Context(), authorizerResultContextKey{}, res)
+
+	return r.WithContext(ctx)
+}
+
+func unmarshalTokenAuthorizerRequest(ctx context.Context, payload []byte) (*http.Request, error) {
+	e := new(events.APIGatewayCustomAuthorizerRequest)
+	if err := json.Unmarshal(payload, e); err != nil {
+		return nil, err
+	}
+
+	apiID, stage, method, path := parseMethodARN(e.MethodArn)
+
+	r, err := newRequest(method, path, "", false)
+	if err != nil {
+		return nil, err
+	}
+
+	r.Header.Set("Authorization", e.AuthorizationToken)
+
+	setRequestFields(r, "", "", "")
+
+	r = WithRequestInfo(r.WithContext(ctx), RequestInfo{
+		Kind:  KindAPIGatewayAuthorizer,
+		APIID: apiID,
+		Stage: stage,
+	})
+
+	return WithEvent(r, e), nil
+}
+
+func unmarshalRequestAuthorizerRequest(ctx context.Context, payload []byte) (*http.Request, error) {
+	e := new(events.APIGatewayCustomAuthorizerRequestTypeRequest)
+	if err := json.Unmarshal(payload, e); err != nil {
+		return nil, err
+	}
+
+	r, err := newRequest(e.HTTPMethod, e.Path, "", false)
+	if err != nil {
+		return nil, err
+	}
+
+	q := r.URL.Query()
+	addMapValues(e.QueryStringParameters, e.MultiValueQueryStringParameters, q.Add)
+	r.URL.RawQuery = q.Encode()
+
+	addMapValues(e.Headers, e.MultiValueHeaders, r.Header.Add)
+
+	setRequestFields(r, e.RequestContext.Identity.SourceIP, "", "")
+
+	r = WithRoute(r.WithContext(ctx), Route{Template: e.Resource, Parameters: e.PathParameters})
+	r = WithRequestInfo(r, RequestInfo{
+		Kind:      KindAPIGatewayAuthorizer,
+		RequestID: e.RequestContext.RequestID,
+		APIID:     e.RequestContext.APIID,
+		Stage:     e.RequestContext.Stage,
+		RouteKey:  e.Resource,
+		SourceIP:  e.RequestContext.Identity.SourceIP,
+		UserAgent: r.UserAgent(),
+	})
+
+	return WithEvent(r, e), nil
+}
+
+func unmarshalV2AuthorizerRequest(ctx context.Context, payload []byte) (*http.Request, error) {
+	e := new(events.APIGatewayV2CustomAuthorizerV2Request)
+	if err := json.Unmarshal(payload, e); err != nil {
+		return nil, err
+	}
+
+	r, err := newRequest(e.RequestContext.HTTP.Method, e.RawPath, "", false)
+	if err != nil {
+		return nil, err
+	}
+
+	setQuery(r, e.RawQueryString, e.QueryStringParameters)
+
+	addMapValues(e.Headers, nil, r.Header.Add)
+	addCookies(e.Cookies, r.Header)
+
+	setRequestFields(r, e.RequestContext.HTTP.SourceIP, e.RequestContext.DomainName, e.RequestContext.HTTP.Protocol)
+
+	r = WithRoute(r.WithContext(ctx), Route{Template: routeTemplate(e.RouteKey), Parameters: e.PathParameters})
+	r = WithRequestInfo(r, RequestInfo{
+		Kind:       KindAPIGatewayAuthorizer,
+		RequestID:  e.RequestContext.RequestID,
+		APIID:      e.RequestContext.APIID,
+		Stage:      e.RequestContext.Stage,
+		RouteKey:   e.RouteKey,
+		SourceIP:   e.RequestContext.HTTP.SourceIP,
+		UserAgent:  e.RequestContext.HTTP.UserAgent,
+		DomainName: e.RequestContext.DomainName,
+		Time:       epochMillis(e.RequestContext.TimeEpoch),
+	})
+
+	return WithEvent(r, e), nil
+}
+
+// parseMethodARN parses a method ARN, e.g. arn:aws:execute-api:region:account:apiid/stage/GET/path
+func parseMethodARN(arn string) (apiID, stage, method, path string) {
+	method, path = http.MethodGet, "/"
+
+	ps := strings.SplitN(arn, ":", 6)
+	if len(ps) < 6 {
+		return
+	}
+
+	ss := strings.SplitN(ps[5], "/", 4)
+	switch len(ss) {
+	case 4:
+		path += ss[3]
+		fallthrough
+	case 3:
+		if ss[2] != "*" && ss[2] != "" {
+			method = ss[2]
+		}
+		fallthrough
+	case 2:
+		stage = ss[1]
+		fallthrough
+	default:
+		apiID = ss[0]
+	}
+
+	return
+}
+
+func authorizerPolicy(allow bool, resources []string) events.APIGatewayCustomAuthorizerPolicy {
+	effect := "Deny"
+	if allow {
+		effect = "Allow"
+	}
+
+	return events.APIGatewayCustomAuthorizerPolicy{
+		Version: authorizerPolicyVersion,
+		Statement: []events.IAMPolicyStatement{{
+			Action:   []string{authorizerPolicyAction},
+			Effect:   effect,
+			Resource: resources,
+		}},
+	}
+}
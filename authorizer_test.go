@@ -0,0 +1,186 @@
+package chop_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stevecallear/chop/v2"
+)
+
+func TestAuthorizer_Invoke(t *testing.T) {
+	const (
+		tokenPayload   = `{"type":"TOKEN","authorizationToken":"Bearer token","methodArn":"arn:aws:execute-api:eu-west-1:123456789012:apiid/dev/GET/users/1"}`
+		requestPayload = `{"type":"REQUEST","methodArn":"arn:aws:execute-api:eu-west-1:123456789012:apiid/dev/GET/users/1","resource":"/users/{id}","path":"/users/1","httpMethod":"GET","headers":{"Authorization":"Bearer token"},"queryStringParameters":{"a":"b"},"pathParameters":{"id":"1"},"requestContext":{"apiId":"apiid","stage":"dev","requestId":"requestid","identity":{"sourceIp":"192.0.2.1"}}}`
+		v2Payload      = `{"version":"2.0","type":"REQUEST","routeArn":"arn:aws:execute-api:eu-west-1:123456789012:apiid/$default/GET/users/1","routeKey":"GET /users/{id}","rawPath":"/users/1","rawQueryString":"a=b","headers":{"authorization":"Bearer token"},"queryStringParameters":{"a":"b"},"pathParameters":{"id":"1"},"requestContext":{"apiId":"apiid","stage":"$default","requestId":"requestid","http":{"method":"GET","sourceIp":"192.0.2.1"}}}`
+	)
+
+	allow := func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" || r.URL.Path != "/users/1" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		res, _ := chop.GetAuthorizerResult(r)
+		res.PrincipalID = "user"
+		res.Context["tenant"] = "t1"
+	}
+
+	tests := []struct {
+		name    string
+		opts    []chop.Option
+		handler http.HandlerFunc
+		payload string
+		exp     string
+		err     bool
+	}{
+		{
+			name:    "should return an error for unsupported events",
+			handler: allow,
+			payload: apiGatewayProxyEventPayload,
+			err:     true,
+		},
+		{
+			name:    "should return an error for invalid events",
+			handler: allow,
+			payload: `{"type":"REQUEST","methodArn":"arn","headers":1}`,
+			err:     true,
+		},
+		{
+			name:    "should allow token requests",
+			handler: allow,
+			payload: tokenPayload,
+			exp:     `{"principalId":"user","policyDocument":{"Version":"2012-10-17","Statement":[{"Action":["execute-api:Invoke"],"Effect":"Allow","Resource":["arn:aws:execute-api:eu-west-1:123456789012:apiid/dev/GET/users/1"]}]},"context":{"tenant":"t1"}}`,
+		},
+		{
+			name: "should deny token requests",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusForbidden)
+			},
+			payload: tokenPayload,
+			exp:     `{"principalId":"","policyDocument":{"Version":"2012-10-17","Statement":[{"Action":["execute-api:Invoke"],"Effect":"Deny","Resource":["arn:aws:execute-api:eu-west-1:123456789012:apiid/dev/GET/users/1"]}]}}`,
+		},
+		{
+			name: "should return unauthorized errors",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusUnauthorized)
+			},
+			payload: tokenPayload,
+			err:     true,
+		},
+		{
+			name: "should deny requests if the handler panics",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				panic("error")
+			},
+			payload: tokenPayload,
+			exp:     `{"principalId":"","policyDocument":{"Version":"2012-10-17","Statement":[{"Action":["execute-api:Invoke"],"Effect":"Deny","Resource":["arn:aws:execute-api:eu-west-1:123456789012:apiid/dev/GET/users/1"]}]}}`,
+		},
+		{
+			name: "should allow request requests with custom resources",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				if rt, _ := chop.GetRoute(r); rt.Template != "/users/{id}" || r.URL.Query().Get("a") != "b" {
+					w.WriteHeader(http.StatusForbidden)
+					return
+				}
+
+				res, _ := chop.GetAuthorizerResult(r)
+				res.PrincipalID = "user"
+				res.Resources = []string{"arn:aws:execute-api:eu-west-1:123456789012:apiid/dev/*"}
+				res.UsageIdentifierKey = "key"
+			},
+			payload: requestPayload,
+			exp:     `{"principalId":"user","policyDocument":{"Version":"2012-10-17","Statement":[{"Action":["execute-api:Invoke"],"Effect":"Allow","Resource":["arn:aws:execute-api:eu-west-1:123456789012:apiid/dev/*"]}]},"usageIdentifierKey":"key"}`,
+		},
+		{
+			name:    "should allow http v2 requests",
+			handler: allow,
+			payload: v2Payload,
+			exp:     `{"principalId":"user","policyDocument":{"Version":"2012-10-17","Statement":[{"Action":["execute-api:Invoke"],"Effect":"Allow","Resource":["arn:aws:execute-api:eu-west-1:123456789012:apiid/$default/GET/users/1"]}]},"context":{"tenant":"t1"}}`,
+		},
+		{
+			name:    "should return simple responses for http v2 requests",
+			opts:    []chop.Option{chop.WithSimpleAuthorizerResponses()},
+			handler: allow,
+			payload: v2Payload,
+			exp:     `{"isAuthorized":true,"context":{"tenant":"t1"}}`,
+		},
+		{
+			name: "should return simple deny responses for http v2 requests",
+			opts: []chop.Option{chop.WithSimpleAuthorizerResponses()},
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusForbidden)
+			},
+			payload: v2Payload,
+			exp:     `{"isAuthorized":false}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			captureLog(t)
+
+			a := chop.WrapAuthorizer(tt.handler, tt.opts...)
+
+			b, err := a.Invoke(context.Background(), []byte(tt.payload))
+			assertErrorExists(t, err, tt.err)
+			if err == nil {
+				assertDeepEqual(t, string(b), tt.exp)
+			}
+		})
+	}
+}
+
+func TestAuthorizer_Invoke_RequestInfo(t *testing.T) {
+	var act chop.RequestInfo
+	a := chop.WrapAuthorizer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		act, _ = chop.GetRequestInfo(r)
+	}))
+
+	_, err := a.Invoke(context.Background(), []byte(`{"type":"TOKEN","authorizationToken":"token","methodArn":"arn:aws:execute-api:eu-west-1:123456789012:apiid/dev/GET/"}`))
+	assertErrorExists(t, err, false)
+	assertDeepEqual(t, act, chop.RequestInfo{
+		Kind:  chop.KindAPIGatewayAuthorizer,
+		APIID: "apiid",
+		Stage: "dev",
+	})
+}
+
+func TestAuthorizer_Invoke_Recorder(t *testing.T) {
+	const payload = `{"type":"TOKEN","authorizationToken":"token","methodArn":"arn:aws:execute-api:eu-west-1:123456789012:apiid/dev/GET/"}`
+
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		exp     string
+		err     string
+	}{
+		{
+			name:    "should record allowed requests",
+			handler: func(w http.ResponseWriter, r *http.Request) {},
+			exp:     `{"principalId":"","policyDocument":{"Version":"2012-10-17","Statement":[{"Action":["execute-api:Invoke"],"Effect":"Allow","Resource":["arn:aws:execute-api:eu-west-1:123456789012:apiid/dev/GET/"]}]}}`,
+		},
+		{
+			name: "should record unauthorized requests",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusUnauthorized)
+			},
+			err: chop.ErrUnauthorized.Error(),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sink := chop.NewMemorySink()
+			chop.WrapAuthorizer(tt.handler, chop.WithRecorder(sink)).Invoke(context.Background(), []byte(payload))
+
+			recs := sink.Recordings()
+			assertDeepEqual(t, len(recs), 1)
+			if len(recs) == 1 {
+				assertDeepEqual(t, string(recs[0].Payload), payload)
+				assertDeepEqual(t, string(recs[0].Response), tt.exp)
+				assertDeepEqual(t, recs[0].Error, tt.err)
+			}
+		})
+	}
+}
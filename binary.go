@@ -0,0 +1,62 @@
+package chop
+
+import (
+	"mime"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+)
+
+// BinaryPolicy returns true if the response with the specified headers and body is binary
+type BinaryPolicy func(h http.Header, body []byte) bool
+
+// DefaultBinaryPolicy treats responses with a content encoding or a non UTF-8 body as binary
+var DefaultBinaryPolicy = BinaryAny(BinaryContentEncoding(), BinaryInvalidUTF8())
+
+// BinaryContentTypes returns a binary policy that treats responses with the specified content types as binary
+// Wildcard subtypes are supported, e.g. image/*
+func BinaryContentTypes(contentTypes ...string) BinaryPolicy {
+	return func(h http.Header, _ []byte) bool {
+		mt, _, err := mime.ParseMediaType(h.Get("Content-Type"))
+		if err != nil {
+			return false
+		}
+
+		for _, ct := range contentTypes {
+			ct = strings.ToLower(ct)
+			if ct == mt || (strings.HasSuffix(ct, "/*") && strings.HasPrefix(mt, ct[:len(ct)-1])) {
+				return true
+			}
+		}
+
+		return false
+	}
+}
+
+// BinaryContentEncoding returns a binary policy that treats responses with a content encoding as binary
+func BinaryContentEncoding() BinaryPolicy {
+	return func(h http.Header, _ []byte) bool {
+		ce := h.Get("Content-Encoding")
+		return ce != "" && !strings.EqualFold(ce, "identity")
+	}
+}
+
+// BinaryInvalidUTF8 returns a binary policy that treats responses with a non UTF-8 body as binary
+func BinaryInvalidUTF8() BinaryPolicy {
+	return func(_ http.Header, body []byte) bool {
+		return !utf8.Valid(body)
+	}
+}
+
+// BinaryAny returns a binary policy that treats responses as binary if any of the specified policies do
+func BinaryAny(policies ...BinaryPolicy) BinaryPolicy {
+	return func(h http.Header, body []byte) bool {
+		for _, p := range policies {
+			if p(h, body) {
+				return true
+			}
+		}
+
+		return false
+	}
+}
@@ -0,0 +1,100 @@
+package chop_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stevecallear/chop/v2"
+)
+
+func TestBinaryPolicy(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy chop.BinaryPolicy
+		header http.Header
+		body   []byte
+		exp    bool
+	}{
+		{
+			name:   "should match content types",
+			policy: chop.BinaryContentTypes("application/pdf"),
+			header: http.Header{"Content-Type": {"application/pdf"}},
+			exp:    true,
+		},
+		{
+			name:   "should match content types with parameters",
+			policy: chop.BinaryContentTypes("application/octet-stream"),
+			header: http.Header{"Content-Type": {"Application/Octet-Stream; charset=binary"}},
+			exp:    true,
+		},
+		{
+			name:   "should match wildcard content types",
+			policy: chop.BinaryContentTypes("image/*"),
+			header: http.Header{"Content-Type": {"image/png"}},
+			exp:    true,
+		},
+		{
+			name:   "should not match other content types",
+			policy: chop.BinaryContentTypes("image/*", "application/pdf"),
+			header: http.Header{"Content-Type": {"text/plain"}},
+			exp:    false,
+		},
+		{
+			name:   "should not match invalid content types",
+			policy: chop.BinaryContentTypes("image/*"),
+			header: http.Header{"Content-Type": {"image/png;;"}},
+			exp:    false,
+		},
+		{
+			name:   "should match content encoding",
+			policy: chop.BinaryContentEncoding(),
+			header: http.Header{"Content-Encoding": {"gzip"}},
+			exp:    true,
+		},
+		{
+			name:   "should not match identity content encoding",
+			policy: chop.BinaryContentEncoding(),
+			header: http.Header{"Content-Encoding": {"identity"}},
+			exp:    false,
+		},
+		{
+			name:   "should not match missing content encoding",
+			policy: chop.BinaryContentEncoding(),
+			header: http.Header{},
+			exp:    false,
+		},
+		{
+			name:   "should match invalid utf-8",
+			policy: chop.BinaryInvalidUTF8(),
+			body:   []byte{0x89, 0x50, 0x4e, 0x47},
+			exp:    true,
+		},
+		{
+			name:   "should not match valid utf-8",
+			policy: chop.BinaryInvalidUTF8(),
+			body:   []byte("body ✓"),
+			exp:    false,
+		},
+		{
+			name:   "should match any policy",
+			policy: chop.BinaryAny(chop.BinaryInvalidUTF8(), chop.BinaryContentEncoding()),
+			header: http.Header{"Content-Encoding": {"br"}},
+			body:   []byte("body"),
+			exp:    true,
+		},
+		{
+			name:   "should not match if no policy matches",
+			policy: chop.BinaryAny(chop.BinaryInvalidUTF8(), chop.BinaryContentEncoding()),
+			header: http.Header{},
+			body:   []byte("body"),
+			exp:    false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			act := tt.policy(tt.header, tt.body)
+			assertDeepEqual(t, act, tt.exp)
+		})
+	}
+}
@@ -3,180 +3,136 @@ package chop
 import (
 	"bytes"
 	"context"
-	"encoding/json"
-	"errors"
+	"encoding/base64"
 	"fmt"
+	"io"
+	"log"
 	"net/http"
-	"strings"
 
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/aws/aws-lambda-go/lambda"
-	"github.com/tidwall/gjson"
 )
 
 type (
 	// Handler represents a lambda event handler
 	Handler struct {
 		http.Handler
+
+		binaryPolicy  BinaryPolicy
+		processors    registry
+		lambdaOptions []lambda.Option
+		panicHandler  PanicHandler
+		repanic       bool
+		errorHandler  ErrorHandler
+		pathRewriters []PathRewriter
+
+		simpleAuthorizerResponses bool
+		localAddr                 string
+		localEvents               bool
+		recorder                  *recorder
 	}
 
 	// ResponseWriter represents a lambda event response writer
 	ResponseWriter struct {
-		code        int
-		buffer      *bytes.Buffer
-		header      http.Header
-		wroteHeader bool
-	}
-
-	eventProcessor struct {
-		canProcess       func([]byte) bool
-		unmarshalRequest func(context.Context, []byte) (*http.Request, error)
-		marshalResponse  func(*ResponseWriter) ([]byte, error)
+		code         int
+		buffer       *bytes.Buffer
+		header       http.Header
+		wroteHeader  bool
+		binaryPolicy BinaryPolicy
+		stream       io.Writer
+		prelude      func(*ResponseWriter) ([]byte, error)
+		committed    bool
+		forward      *http.Request
 	}
 
 	eventContextKey struct{}
 )
 
-var (
-	// ErrUnsupportedEventType indicates that the received lambda event is not supported
-	ErrUnsupportedEventType = errors.New("unsupported lambda event type")
-
-	apiGatewayProxyEventProcessor = &eventProcessor{
-		canProcess: func(payload []byte) bool {
-			pv := gjson.GetManyBytes(payload, "version", "requestContext.apiId")
-			return !pv[0].Exists() && pv[1].Exists()
-		},
-		unmarshalRequest: func(ctx context.Context, payload []byte) (*http.Request, error) {
-			e := new(events.APIGatewayProxyRequest)
-			if err := json.Unmarshal(payload, e); err != nil {
-				return nil, err
-			}
-
-			r, err := http.NewRequest(e.HTTPMethod, e.Path, bytes.NewBufferString(e.Body))
-			if err != nil {
-				return nil, err
-			}
-
-			q := r.URL.Query()
-			addMapValues(e.QueryStringParameters, e.MultiValueQueryStringParameters, q.Add)
-			r.URL.RawQuery = q.Encode()
-
-			addMapValues(e.Headers, e.MultiValueHeaders, r.Header.Add)
-
-			return WithEvent(r.WithContext(ctx), e), nil
-		},
-		marshalResponse: func(w *ResponseWriter) ([]byte, error) {
-			return json.Marshal(&events.APIGatewayProxyResponse{
-				StatusCode:        w.StatusCode(),
-				Headers:           reduceHeaders(w.Header()),
-				MultiValueHeaders: w.Header(),
-				Body:              w.Body(),
-			})
-		},
-	}
-
-	apiGatewayV2HTTPEventProcessor = &eventProcessor{
-		canProcess: func(payload []byte) bool {
-			pv := gjson.GetManyBytes(payload, "version", "requestContext.apiId")
-			return pv[0].String() == "2.0" && pv[1].Exists()
-		},
-		unmarshalRequest: func(ctx context.Context, payload []byte) (*http.Request, error) {
-			e := new(events.APIGatewayV2HTTPRequest)
-			if err := json.Unmarshal(payload, e); err != nil {
-				return nil, err
-			}
-
-			r, err := http.NewRequest(e.RequestContext.HTTP.Method, e.RawPath, bytes.NewBufferString(e.Body))
-			if err != nil {
-				return nil, err
-			}
-
-			q := r.URL.Query()
-			for k, p := range e.QueryStringParameters {
-				for _, v := range strings.Split(p, ",") {
-					q.Add(k, v)
-				}
-			}
-			r.URL.RawQuery = q.Encode()
-
-			addMapValues(e.Headers, nil, r.Header.Add)
-
-			return WithEvent(r.WithContext(ctx), e), nil
-		},
-		marshalResponse: func(w *ResponseWriter) ([]byte, error) {
-			return json.Marshal(&events.APIGatewayV2HTTPResponse{
-				StatusCode:        w.StatusCode(),
-				Headers:           reduceHeaders(w.Header()),
-				MultiValueHeaders: w.Header(),
-				Body:              w.Body(),
-				Cookies:           []string{},
-			})
-		},
-	}
-
-	albTargetGroupEventProcessor = &eventProcessor{
-		canProcess: func(payload []byte) bool {
-			return gjson.GetBytes(payload, "requestContext.elb").Exists()
-		},
-		unmarshalRequest: func(ctx context.Context, payload []byte) (*http.Request, error) {
-			e := new(events.ALBTargetGroupRequest)
-			if err := json.Unmarshal(payload, e); err != nil {
-				return nil, err
-			}
-
-			r, err := http.NewRequest(e.HTTPMethod, e.Path, bytes.NewBufferString(e.Body))
-			if err != nil {
-				return nil, err
-			}
-
-			q := r.URL.Query()
-			addMapValues(e.QueryStringParameters, e.MultiValueQueryStringParameters, q.Add)
-			r.URL.RawQuery = q.Encode()
-
-			addMapValues(e.Headers, e.MultiValueHeaders, r.Header.Add)
-
-			return WithEvent(r.WithContext(ctx), e), nil
-		},
-		marshalResponse: func(w *ResponseWriter) ([]byte, error) {
-			return json.Marshal(&events.ALBTargetGroupResponse{
-				StatusCode:        w.StatusCode(),
-				StatusDescription: w.Status(),
-				Headers:           reduceHeaders(w.Header()),
-				MultiValueHeaders: w.Header(),
-				Body:              w.Body(),
-			})
-		},
+// Start wraps and starts the specified HTTP handler as a lambda function handler
+// The handler is served locally over HTTP if it is not running in a lambda environment
+func Start(h http.Handler, opts ...Option) {
+	w := Wrap(h, opts...)
+	if addr, ok := w.isLocal(); ok {
+		log.Fatal(w.listenLocal(addr))
 	}
-)
 
-// Start wraps and starts the specified HTTP handler as a lambda function handler
-func Start(h http.Handler) {
-	lambda.StartHandler(Wrap(h))
+	lambda.StartWithOptions(w, w.lambdaOptions...)
 }
 
 // Wrap wraps the specified HTTP handler as a lambda function handler
-func Wrap(h http.Handler) *Handler {
-	return &Handler{
+func Wrap(h http.Handler, opts ...Option) *Handler {
+	w := &Handler{
 		Handler: h,
 	}
+
+	for _, o := range opts {
+		o(w)
+	}
+
+	return w
 }
 
 // Invoke invokes the lambda function handler
 func (h *Handler) Invoke(ctx context.Context, payload []byte) ([]byte, error) {
-	p, err := getEventProcessor(payload)
+	res, err := h.invoke(ctx, payload)
+	if h.recorder != nil {
+		h.recorder.record(ctx, payload, res, err)
+	}
+
+	return res, err
+}
+
+// RegisterEventProcessor registers the specified event processor for the handler
+// Processors are evaluated in ascending priority order, with handler processors evaluated
+// before global processors of the same priority
+func (h *Handler) RegisterEventProcessor(p EventProcessor, priority int) {
+	h.processors.register(p, priority)
+}
+
+func (h *Handler) invoke(ctx context.Context, payload []byte) ([]byte, error) {
+	w := NewResponseWriter()
+	w.binaryPolicy = h.binaryPolicy
+
+	p, err := h.getEventProcessor(payload)
 	if err != nil {
-		return nil, err
+		return nil, h.handleError(w, err, nil)
 	}
 
-	r, err := p.unmarshalRequest(ctx, payload)
+	r, err := h.unmarshalRequest(ctx, p, payload)
+	if err != nil {
+		if err = h.handleError(w, err, p); err != nil {
+			return nil, err
+		}
+
+		return p.MarshalResponse(w)
+	}
+
+	h.serveHTTP(w, r)
+
+	return p.MarshalResponse(w)
+}
+
+func (h *Handler) unmarshalRequest(ctx context.Context, p EventProcessor, payload []byte) (*http.Request, error) {
+	r, err := p.UnmarshalRequest(ctx, payload)
 	if err != nil {
 		return nil, err
 	}
 
-	w := NewResponseWriter()
-	h.ServeHTTP(w, r)
+	for _, rw := range h.pathRewriters {
+		rw(r)
+	}
 
-	return p.marshalResponse(w)
+	return r, nil
+}
+
+func (h *Handler) getEventProcessor(payload []byte) (EventProcessor, error) {
+	for _, p := range mergeProcessors(h.processors.get(), defaultRegistry.get()) {
+		if p.CanProcess(payload) {
+			return p, nil
+		}
+	}
+
+	return nil, ErrUnsupportedEventType
 }
 
 // NewResponseWriter returns a new ResponseWriter
@@ -203,6 +159,30 @@ func (w *ResponseWriter) Body() string {
 	return w.buffer.String()
 }
 
+// Bytes returns the response body as a byte slice
+func (w *ResponseWriter) Bytes() []byte {
+	return w.buffer.Bytes()
+}
+
+// IsBinary returns true if the response body is binary according to the binary policy
+func (w *ResponseWriter) IsBinary() bool {
+	p := w.binaryPolicy
+	if p == nil {
+		p = DefaultBinaryPolicy
+	}
+
+	return p(w.header, w.buffer.Bytes())
+}
+
+// EncodedBody returns the response body, base64 encoded if it is binary
+func (w *ResponseWriter) EncodedBody() (body string, isBase64Encoded bool) {
+	if w.IsBinary() {
+		return base64.StdEncoding.EncodeToString(w.buffer.Bytes()), true
+	}
+
+	return w.buffer.String(), false
+}
+
 // Header returns the response headers
 func (w *ResponseWriter) Header() http.Header {
 	return w.header
@@ -210,11 +190,31 @@ func (w *ResponseWriter) Header() http.Header {
 
 func (w *ResponseWriter) Write(b []byte) (int, error) {
 	w.writeHeader(b)
+
+	if w.stream != nil {
+		if err := w.commit(); err != nil {
+			return 0, err
+		}
+
+		return w.stream.Write(b)
+	}
+
 	w.buffer.Write(b)
 
 	return len(b), nil
 }
 
+// Flush writes the response status and headers if the response is being streamed
+// Flush is a no-op for buffered responses
+func (w *ResponseWriter) Flush() {
+	if w.stream == nil {
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.commit()
+}
+
 // WriteHeader writes the specified status if the header has not been written
 func (w *ResponseWriter) WriteHeader(code int) {
 	if w.wroteHeader {
@@ -238,6 +238,30 @@ func (w *ResponseWriter) writeHeader(b []byte) {
 	w.WriteHeader(http.StatusOK)
 }
 
+func (w *ResponseWriter) reset() {
+	w.code = http.StatusOK
+	w.buffer.Reset()
+	w.header = http.Header{}
+	w.wroteHeader = false
+	w.forward = nil
+}
+
+func (w *ResponseWriter) commit() error {
+	if w.committed {
+		return nil
+	}
+
+	w.committed = true
+
+	b, err := w.prelude(w)
+	if err != nil {
+		return err
+	}
+
+	_, err = w.stream.Write(append(b, streamingPreludeDelimiter...))
+	return err
+}
+
 // WithEvent returns a copy of the request with the specified event stored in the request context
 func WithEvent(r *http.Request, event interface{}) *http.Request {
 	ctx := context.WithValue(r.Context(), eventContextKey{}, event)
@@ -250,41 +274,43 @@ func GetEvent(r *http.Request) interface{} {
 	return r.Context().Value(eventContextKey{})
 }
 
-func getEventProcessor(payload []byte) (*eventProcessor, error) {
-	for _, p := range []*eventProcessor{
-		apiGatewayProxyEventProcessor,
-		apiGatewayV2HTTPEventProcessor,
-		albTargetGroupEventProcessor,
-	} {
-		if p.canProcess(payload) {
-			return p, nil
-		}
-	}
+// GetEventAs returns the lambda event stored within the specified request context if it exists and is of type T
+func GetEventAs[T any](r *http.Request) (T, bool) {
+	e, ok := GetEvent(r).(T)
+	return e, ok
+}
 
-	return nil, ErrUnsupportedEventType
+// GetAPIGatewayProxyRequest returns the api gateway proxy event stored within the specified request context if it exists
+func GetAPIGatewayProxyRequest(r *http.Request) (*events.APIGatewayProxyRequest, bool) {
+	return GetEventAs[*events.APIGatewayProxyRequest](r)
 }
 
-func addMapValues(values map[string]string, multiValues map[string][]string, addFn func(string, string)) {
-	if len(multiValues) > 1 {
-		for k, mv := range multiValues {
-			for _, v := range mv {
-				addFn(k, v)
-			}
-		}
+// GetAPIGatewayV2HTTPRequest returns the api gateway http v2 event stored within the specified request context if it exists
+func GetAPIGatewayV2HTTPRequest(r *http.Request) (*events.APIGatewayV2HTTPRequest, bool) {
+	return GetEventAs[*events.APIGatewayV2HTTPRequest](r)
+}
 
-		return
-	}
+// GetLambdaFunctionURLRequest returns the lambda function url event stored within the specified request context if it exists
+func GetLambdaFunctionURLRequest(r *http.Request) (*events.LambdaFunctionURLRequest, bool) {
+	return GetEventAs[*events.LambdaFunctionURLRequest](r)
+}
 
-	for k, v := range values {
-		addFn(k, v)
-	}
+// GetALBTargetGroupRequest returns the alb target group event stored within the specified request context if it exists
+func GetALBTargetGroupRequest(r *http.Request) (*events.ALBTargetGroupRequest, bool) {
+	return GetEventAs[*events.ALBTargetGroupRequest](r)
 }
 
-func reduceHeaders(h http.Header) map[string]string {
-	m := make(map[string]string, len(h))
-	for k := range h {
-		m[k] = h.Get(k)
-	}
+// GetVPCLatticeRequest returns the vpc lattice event stored within the specified request context if it exists
+func GetVPCLatticeRequest(r *http.Request) (*VPCLatticeRequest, bool) {
+	return GetEventAs[*VPCLatticeRequest](r)
+}
+
+// GetVPCLatticeRequestV2 returns the vpc lattice v2 event stored within the specified request context if it exists
+func GetVPCLatticeRequestV2(r *http.Request) (*VPCLatticeRequestV2, bool) {
+	return GetEventAs[*VPCLatticeRequestV2](r)
+}
 
-	return m
+// GetCloudFrontEvent returns the cloudfront lambda@edge event stored within the specified request context if it exists
+func GetCloudFrontEvent(r *http.Request) (*CloudFrontEvent, bool) {
+	return GetEventAs[*CloudFrontEvent](r)
 }
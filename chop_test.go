@@ -6,16 +6,20 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"log"
 	"net/http"
+	"net/http/httptest"
 	"net/rpc"
 	"os"
 	"reflect"
+	"strconv"
 	"testing"
 	"time"
 
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/aws/aws-lambda-go/lambda/messages"
 	"github.com/aws/aws-lambda-go/lambdacontext"
+	"github.com/tidwall/gjson"
 
 	"github.com/stevecallear/chop/v2"
 )
@@ -65,6 +69,19 @@ func TestStart(t *testing.T) {
 				Cookies: []string{},
 			},
 		},
+		{
+			name:    "should handle lambda function url events",
+			payload: lambdaFunctionURLEventPayload,
+			act:     new(events.LambdaFunctionURLResponse),
+			exp: &events.LambdaFunctionURLResponse{
+				StatusCode: http.StatusOK,
+				Headers: map[string]string{
+					"Content-Type": "text/plain; charset=utf-8",
+				},
+				Body:    "*lambdacontext.LambdaContext|*events.LambdaFunctionURLRequest",
+				Cookies: []string{},
+			},
+		},
 		{
 			name:    "should handle alb target group events",
 			payload: albTargetGroupSingleValueEventPayload,
@@ -175,6 +192,22 @@ func TestHandler_Invoke(t *testing.T) {
 				Body: "body",
 			},
 		},
+		{
+			name:    "should decode base64 encoded api gateway proxy event bodies",
+			payload: `{"httpMethod":"POST","path":"/","requestContext":{"apiId":"id"},"body":"iVBORw0K","isBase64Encoded":true}`,
+			handlerFn: func(t *testing.T) http.HandlerFunc {
+				return func(w http.ResponseWriter, r *http.Request) {
+					assertDeepEqual(t, r.ContentLength, int64(6))
+					assertDeepEqual(t, toRequest(r).body, "\x89PNG\r\n")
+				}
+			},
+			act: &events.APIGatewayProxyResponse{},
+			exp: &events.APIGatewayProxyResponse{
+				StatusCode:        http.StatusOK,
+				Headers:           map[string]string{},
+				MultiValueHeaders: map[string][]string{},
+			},
+		},
 		{
 			name:    "should return an error if the api gateway http v2 event cannot be unmarshalled",
 			payload: `{"version":"2.0","requestContext":{"apiId":"id"},"resource":"a}`,
@@ -219,7 +252,7 @@ func TestHandler_Invoke(t *testing.T) {
 				StatusCode: http.StatusOK,
 				Headers: map[string]string{
 					"Content-Type":    "text/plain; charset=utf-8",
-					"X-Custom-Header": "v1",
+					"X-Custom-Header": "v1,v2",
 				},
 				MultiValueHeaders: map[string][]string{
 					"Content-Type":    {"text/plain; charset=utf-8"},
@@ -229,6 +262,157 @@ func TestHandler_Invoke(t *testing.T) {
 				Cookies: []string{},
 			},
 		},
+		{
+			name:    "should join repeated api gateway http v2 response headers",
+			payload: apiGatewayV2HTTPEventPayload,
+			handlerFn: func(t *testing.T) http.HandlerFunc {
+				return func(w http.ResponseWriter, r *http.Request) {
+					w.Header().Add("Vary", "Accept")
+					w.Header().Add("Vary", "Origin")
+				}
+			},
+			act: &events.APIGatewayV2HTTPResponse{},
+			exp: &events.APIGatewayV2HTTPResponse{
+				StatusCode: http.StatusOK,
+				Headers: map[string]string{
+					"Vary": "Accept,Origin",
+				},
+				MultiValueHeaders: map[string][]string{
+					"Vary": {"Accept", "Origin"},
+				},
+				Cookies: []string{},
+			},
+		},
+		{
+			name:    "should decode base64 encoded api gateway http v2 event bodies",
+			payload: `{"version":"2.0","rawPath":"/","requestContext":{"apiId":"id","http":{"method":"POST"}},"body":"Ym9keQ==","isBase64Encoded":true}`,
+			handlerFn: func(t *testing.T) http.HandlerFunc {
+				return func(w http.ResponseWriter, r *http.Request) {
+					assertDeepEqual(t, r.ContentLength, int64(4))
+					assertDeepEqual(t, toRequest(r).body, "body")
+				}
+			},
+			act: &events.APIGatewayV2HTTPResponse{},
+			exp: &events.APIGatewayV2HTTPResponse{
+				StatusCode:        http.StatusOK,
+				Headers:           map[string]string{},
+				MultiValueHeaders: map[string][]string{},
+				Cookies:           []string{},
+			},
+		},
+		{
+			name:    "should handle api gateway http v2 event cookies",
+			payload: `{"version":"2.0","rawPath":"/","cookies":["c1=v1","c2=v2"],"requestContext":{"apiId":"id","http":{"method":"GET"}}}`,
+			handlerFn: func(t *testing.T) http.HandlerFunc {
+				return func(w http.ResponseWriter, r *http.Request) {
+					exp := []*http.Cookie{{Name: "c1", Value: "v1"}, {Name: "c2", Value: "v2"}}
+					assertDeepEqual(t, r.Cookies(), exp)
+
+					http.SetCookie(w, &http.Cookie{Name: "c3", Value: "v3"})
+					http.SetCookie(w, &http.Cookie{Name: "c4", Value: "v4", HttpOnly: true})
+					w.Header().Set("X-Custom-Header", "v1")
+				}
+			},
+			act: &events.APIGatewayV2HTTPResponse{},
+			exp: &events.APIGatewayV2HTTPResponse{
+				StatusCode: http.StatusOK,
+				Headers: map[string]string{
+					"X-Custom-Header": "v1",
+				},
+				MultiValueHeaders: map[string][]string{
+					"X-Custom-Header": {"v1"},
+				},
+				Cookies: []string{"c3=v3", "c4=v4; HttpOnly"},
+			},
+		},
+		{
+			name:    "should return an error if the lambda function url event cannot be unmarshalled",
+			payload: `{"version":"2.0","requestContext":{"domainName":"id.lambda-url.eu-west-1.on.aws"},"resource":"a}`,
+			handlerFn: func(t *testing.T) http.HandlerFunc {
+				return func(http.ResponseWriter, *http.Request) {}
+			},
+			err: true,
+		},
+		{
+			name:    "should return an error if the lambda function url event path is invalid",
+			payload: `{"version":"2.0","rawPath":"/resource###%","requestContext":{"domainName":"id.lambda-url.eu-west-1.on.aws"}}`,
+			handlerFn: func(t *testing.T) http.HandlerFunc {
+				return func(http.ResponseWriter, *http.Request) {}
+			},
+			err: true,
+		},
+		{
+			name:    "should handle lambda function url events",
+			payload: lambdaFunctionURLEventPayload,
+			handlerFn: func(t *testing.T) http.HandlerFunc {
+				return func(w http.ResponseWriter, r *http.Request) {
+					exp := request{
+						method: "GET",
+						url:    "/resource/?q1=v1&q2=v2&q2=v3",
+						body:   "body",
+						header: http.Header{
+							"X-Custom-Header1": {"v1"},
+							"X-Custom-Header2": {"v2"},
+						},
+					}
+
+					act := toRequest(r)
+					assertDeepEqual(t, act, exp)
+
+					w.Header().Add("X-Custom-Header", "v1")
+					w.Header().Add("X-Custom-Header", "v2")
+					w.Write([]byte("body"))
+				}
+			},
+			act: &events.LambdaFunctionURLResponse{},
+			exp: &events.LambdaFunctionURLResponse{
+				StatusCode: http.StatusOK,
+				Headers: map[string]string{
+					"Content-Type":    "text/plain; charset=utf-8",
+					"X-Custom-Header": "v1,v2",
+				},
+				Body:    "body",
+				Cookies: []string{},
+			},
+		},
+		{
+			name:    "should decode base64 encoded lambda function url event bodies",
+			payload: `{"version":"2.0","rawPath":"/","requestContext":{"domainName":"id.lambda-url.eu-west-1.on.aws","http":{"method":"POST"}},"body":"Ym9keQ==","isBase64Encoded":true}`,
+			handlerFn: func(t *testing.T) http.HandlerFunc {
+				return func(w http.ResponseWriter, r *http.Request) {
+					assertDeepEqual(t, r.ContentLength, int64(4))
+					assertDeepEqual(t, toRequest(r).body, "body")
+				}
+			},
+			act: &events.LambdaFunctionURLResponse{},
+			exp: &events.LambdaFunctionURLResponse{
+				StatusCode: http.StatusOK,
+				Headers:    map[string]string{},
+				Cookies:    []string{},
+			},
+		},
+		{
+			name:    "should handle lambda function url event cookies",
+			payload: `{"version":"2.0","rawPath":"/","cookies":["c1=v1","c2=v2"],"requestContext":{"domainName":"id.lambda-url.eu-west-1.on.aws","http":{"method":"GET"}}}`,
+			handlerFn: func(t *testing.T) http.HandlerFunc {
+				return func(w http.ResponseWriter, r *http.Request) {
+					exp := []*http.Cookie{{Name: "c1", Value: "v1"}, {Name: "c2", Value: "v2"}}
+					assertDeepEqual(t, r.Cookies(), exp)
+
+					http.SetCookie(w, &http.Cookie{Name: "c3", Value: "v3"})
+					http.SetCookie(w, &http.Cookie{Name: "c4", Value: "v4", HttpOnly: true})
+					w.Header().Set("X-Custom-Header", "v1")
+				}
+			},
+			act: &events.LambdaFunctionURLResponse{},
+			exp: &events.LambdaFunctionURLResponse{
+				StatusCode: http.StatusOK,
+				Headers: map[string]string{
+					"X-Custom-Header": "v1",
+				},
+				Cookies: []string{"c3=v3", "c4=v4; HttpOnly"},
+			},
+		},
 		{
 			name:    "should return an error if the alb target group event cannot be unmarshalled",
 			payload: `{"requestContext":{"elb":{}},"resource":"a}`,
@@ -321,6 +505,23 @@ func TestHandler_Invoke(t *testing.T) {
 				Body: "body",
 			},
 		},
+		{
+			name:    "should decode base64 encoded alb target group event bodies",
+			payload: `{"httpMethod":"POST","path":"/","requestContext":{"elb":{}},"body":"Ym9keQ==","isBase64Encoded":true}`,
+			handlerFn: func(t *testing.T) http.HandlerFunc {
+				return func(w http.ResponseWriter, r *http.Request) {
+					assertDeepEqual(t, r.ContentLength, int64(4))
+					assertDeepEqual(t, toRequest(r).body, "body")
+				}
+			},
+			act: &events.ALBTargetGroupResponse{},
+			exp: &events.ALBTargetGroupResponse{
+				StatusCode:        http.StatusOK,
+				StatusDescription: toStatusDescription(http.StatusOK),
+				Headers:           map[string]string{},
+				MultiValueHeaders: map[string][]string{},
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -340,6 +541,165 @@ func TestHandler_Invoke(t *testing.T) {
 	}
 }
 
+func TestHandler_Invoke_RequestFields(t *testing.T) {
+	type fields struct {
+		remoteAddr    string
+		host          string
+		proto         string
+		tls           bool
+		serverName    string
+		requestURI    string
+		contentLength int64
+		hostHeader    string
+	}
+
+	tests := []struct {
+		name    string
+		payload string
+		exp     fields
+	}{
+		{
+			name:    "should set api gateway proxy request fields",
+			payload: `{"httpMethod":"POST","path":"/resource","queryStringParameters":{"q":"v"},"headers":{"Host":"api.example.com","X-Forwarded-Proto":"https"},"requestContext":{"apiId":"id","domainName":"id.execute-api.eu-west-1.amazonaws.com","protocol":"HTTP/1.1","identity":{"sourceIp":"192.0.2.1"}},"body":"body"}`,
+			exp: fields{
+				remoteAddr:    "192.0.2.1",
+				host:          "api.example.com",
+				proto:         "HTTP/1.1",
+				tls:           true,
+				serverName:    "api.example.com",
+				requestURI:    "/resource?q=v",
+				contentLength: 4,
+			},
+		},
+		{
+			name:    "should use the api gateway proxy domain name if the host header is not set",
+			payload: `{"httpMethod":"GET","path":"/resource","requestContext":{"apiId":"id","domainName":"id.execute-api.eu-west-1.amazonaws.com","protocol":"HTTP/2.0","identity":{"sourceIp":"192.0.2.1"}}}`,
+			exp: fields{
+				remoteAddr: "192.0.2.1",
+				host:       "id.execute-api.eu-west-1.amazonaws.com",
+				proto:      "HTTP/2.0",
+				requestURI: "/resource",
+			},
+		},
+		{
+			name:    "should set api gateway http v2 request fields",
+			payload: `{"version":"2.0","rawPath":"/resource","queryStringParameters":{"q":"v"},"headers":{"host":"id.execute-api.eu-west-1.amazonaws.com:443","x-forwarded-proto":"https"},"requestContext":{"apiId":"id","domainName":"id.execute-api.eu-west-1.amazonaws.com","http":{"method":"POST","protocol":"HTTP/1.1","sourceIp":"192.0.2.1"}},"body":"body"}`,
+			exp: fields{
+				remoteAddr:    "192.0.2.1",
+				host:          "id.execute-api.eu-west-1.amazonaws.com:443",
+				proto:         "HTTP/1.1",
+				tls:           true,
+				serverName:    "id.execute-api.eu-west-1.amazonaws.com",
+				requestURI:    "/resource?q=v",
+				contentLength: 4,
+			},
+		},
+		{
+			name:    "should set lambda function url request fields",
+			payload: `{"version":"2.0","rawPath":"/resource","headers":{"host":"id.lambda-url.eu-west-1.on.aws","x-forwarded-proto":"https"},"requestContext":{"domainName":"id.lambda-url.eu-west-1.on.aws","http":{"method":"GET","protocol":"HTTP/1.1","sourceIp":"192.0.2.1"}}}`,
+			exp: fields{
+				remoteAddr: "192.0.2.1",
+				host:       "id.lambda-url.eu-west-1.on.aws",
+				proto:      "HTTP/1.1",
+				tls:        true,
+				serverName: "id.lambda-url.eu-west-1.on.aws",
+				requestURI: "/resource",
+			},
+		},
+		{
+			name:    "should set alb target group request fields",
+			payload: `{"httpMethod":"GET","path":"/resource","headers":{"host":"lb.example.com","x-forwarded-for":"198.51.100.1, 192.0.2.1","x-forwarded-proto":"http"},"requestContext":{"elb":{}}}`,
+			exp: fields{
+				remoteAddr: "192.0.2.1",
+				host:       "lb.example.com",
+				proto:      "HTTP/1.1",
+				requestURI: "/resource",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var act fields
+			h := chop.Wrap(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				act = fields{
+					remoteAddr:    r.RemoteAddr,
+					host:          r.Host,
+					proto:         r.Proto,
+					tls:           r.TLS != nil,
+					requestURI:    r.RequestURI,
+					contentLength: r.ContentLength,
+					hostHeader:    r.Header.Get("Host"),
+				}
+
+				if r.TLS != nil {
+					act.serverName = r.TLS.ServerName
+				}
+			}))
+
+			_, err := h.Invoke(context.Background(), []byte(tt.payload))
+			assertErrorExists(t, err, false)
+			assertDeepEqual(t, act, tt.exp)
+		})
+	}
+}
+
+func TestGetEventAs(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload string
+		exp     string
+	}{
+		{
+			name:    "should return api gateway proxy events",
+			payload: apiGatewayProxyEventPayload,
+			exp:     "proxy",
+		},
+		{
+			name:    "should return api gateway http v2 events",
+			payload: apiGatewayV2HTTPEventPayload,
+			exp:     "v2",
+		},
+		{
+			name:    "should return lambda function url events",
+			payload: lambdaFunctionURLEventPayload,
+			exp:     "url",
+		},
+		{
+			name:    "should return alb target group events",
+			payload: albTargetGroupSingleValueEventPayload,
+			exp:     "alb",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var act []string
+			h := chop.Wrap(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if e, ok := chop.GetAPIGatewayProxyRequest(r); ok && e != nil {
+					act = append(act, "proxy")
+				}
+				if e, ok := chop.GetAPIGatewayV2HTTPRequest(r); ok && e != nil {
+					act = append(act, "v2")
+				}
+				if e, ok := chop.GetLambdaFunctionURLRequest(r); ok && e != nil {
+					act = append(act, "url")
+				}
+				if e, ok := chop.GetALBTargetGroupRequest(r); ok && e != nil {
+					act = append(act, "alb")
+				}
+				if _, ok := chop.GetEventAs[string](r); ok {
+					act = append(act, "string")
+				}
+			}))
+
+			_, err := h.Invoke(context.Background(), []byte(tt.payload))
+			assertErrorExists(t, err, false)
+			assertDeepEqual(t, act, []string{tt.exp})
+		})
+	}
+}
+
 func TestResponseWriter_Write(t *testing.T) {
 	tests := []struct {
 		name   string
@@ -465,6 +825,74 @@ func TestResponseWriter_Write(t *testing.T) {
 	}
 }
 
+func TestResponseWriter_EncodedBody(t *testing.T) {
+	tests := []struct {
+		name            string
+		policy          chop.BinaryPolicy
+		header          http.Header
+		data            []byte
+		expBody         string
+		expBase64Encode bool
+	}{
+		{
+			name:    "should not encode text bodies",
+			data:    []byte("body"),
+			expBody: "body",
+		},
+		{
+			name:            "should encode binary bodies",
+			data:            []byte("\x89PNG\r\n"),
+			expBody:         "iVBORw0K",
+			expBase64Encode: true,
+		},
+		{
+			name: "should encode content encoded bodies",
+			header: http.Header{
+				"Content-Encoding": {"gzip"},
+			},
+			data:            []byte("body"),
+			expBody:         "Ym9keQ==",
+			expBase64Encode: true,
+		},
+		{
+			name:   "should use the specified policy",
+			policy: chop.BinaryContentTypes("application/pdf"),
+			header: http.Header{
+				"Content-Type": {"application/pdf"},
+			},
+			data:            []byte("body"),
+			expBody:         "Ym9keQ==",
+			expBase64Encode: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := chop.Wrap(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				for k, vs := range tt.header {
+					w.Header()[k] = vs
+				}
+
+				w.Write(tt.data)
+			}), chop.WithBinaryPolicy(tt.policy))
+
+			for _, p := range []string{
+				apiGatewayProxyEventPayload,
+				apiGatewayV2HTTPEventPayload,
+				lambdaFunctionURLEventPayload,
+				albTargetGroupSingleValueEventPayload,
+			} {
+				b, err := h.Invoke(context.Background(), []byte(p))
+				assertErrorExists(t, err, false)
+
+				act := gjson.GetManyBytes(b, "body", "isBase64Encoded")
+				assertDeepEqual(t, act[0].String(), tt.expBody)
+				assertDeepEqual(t, act[1].Bool(), tt.expBase64Encode)
+			}
+		})
+	}
+}
+
 func TestResponseWriter_WriteHeader(t *testing.T) {
 	tests := []struct {
 		name  string
@@ -590,6 +1018,40 @@ func invokeLocal(port string, payload []byte) ([]byte, error) {
 	return res.Payload, nil
 }
 
+func captureLog(t *testing.T) *bytes.Buffer {
+	b := new(bytes.Buffer)
+	log.SetOutput(b)
+	t.Cleanup(func() {
+		log.SetOutput(os.Stderr)
+	})
+
+	return b
+}
+
+func startRuntimeAPI(t *testing.T, payload string, responseFn func(*http.Request)) {
+	next := make(chan struct{}, 1)
+	next <- struct{}{}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/2018-06-01/runtime/invocation/next", func(w http.ResponseWriter, r *http.Request) {
+		<-next // block subsequent invocations
+
+		deadline := time.Now().Add(time.Minute).UnixNano() / int64(time.Millisecond)
+		w.Header().Set("Lambda-Runtime-Aws-Request-Id", "id")
+		w.Header().Set("Lambda-Runtime-Deadline-Ms", strconv.FormatInt(deadline, 10))
+		w.Write([]byte(payload))
+	})
+	mux.HandleFunc("/2018-06-01/runtime/invocation/id/response", func(w http.ResponseWriter, r *http.Request) {
+		responseFn(r)
+		w.WriteHeader(http.StatusAccepted)
+	})
+
+	srv := httptest.NewServer(mux)
+
+	t.Setenv("_LAMBDA_SERVER_PORT", "")
+	t.Setenv("AWS_LAMBDA_RUNTIME_API", srv.Listener.Addr().String())
+}
+
 const (
 	apiGatewayProxyEventPayload = `{
 	"resource": "/{proxy+}",
@@ -661,6 +1123,38 @@ const (
 	"isBase64Encoded": false
 }`
 
+	lambdaFunctionURLEventPayload = `{
+	"version": "2.0",
+	"rawPath": "/resource/",
+	"rawQueryString": "q1=v1&q2=v2&q2=v3",
+	"headers": {
+		"x-custom-header1": "v1",
+		"x-custom-header2": "v2"
+	},
+	"queryStringParameters": {
+		"q1": "v1",
+		"q2": "v2,v3"
+	},
+	"requestContext": {
+		"accountId": "anonymous",
+		"apiId": "urlid",
+		"domainName": "urlid.lambda-url.eu-west-1.on.aws",
+		"domainPrefix": "urlid",
+		"http": {
+			"method": "GET",
+			"path": "/resource/",
+			"protocol": "HTTP/1.1",
+			"sourceIp": "127.0.0.1",
+			"userAgent": "agent"
+		},
+		"requestId": "id",
+		"time": "12/Mar/2020:19:03:58 +0000",
+		"timeEpoch": 1583348638390
+	},
+	"body": "body",
+	"isBase64Encoded": false
+}`
+
 	albTargetGroupSingleValueEventPayload = `{
 	"requestContext": {
 		"elb": {
@@ -0,0 +1,86 @@
+// Package choptest provides utilities for testing chop lambda function handlers
+package choptest
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/aws/aws-lambda-go/events"
+
+	"github.com/stevecallear/chop/v2"
+	"github.com/stevecallear/chop/v2/internal/event"
+)
+
+// NewEvent returns the JSON lambda event payload of the specified kind for the request
+// API gateway proxy, API gateway websocket, API gateway http v2, lambda function url, alb target group,
+// VPC lattice and CloudFront events are supported
+func NewEvent(kind chop.EventKind, r *http.Request) ([]byte, error) {
+	b, err := event.ReadBody(r)
+	if err != nil {
+		return nil, err
+	}
+
+	e, err := event.New(string(kind), r, b)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(e)
+}
+
+// NewAPIGatewayProxyEvent returns an API gateway proxy event for the request
+// The function panics if the request body cannot be read
+func NewAPIGatewayProxyEvent(r *http.Request) *events.APIGatewayProxyRequest {
+	return event.NewAPIGatewayProxy(r, mustReadBody(r))
+}
+
+// NewAPIGatewayWebsocketEvent returns an API gateway websocket message event for the request
+// The route key is the request path without the leading slash. The function panics if the request body cannot be read.
+func NewAPIGatewayWebsocketEvent(r *http.Request) *events.APIGatewayWebsocketProxyRequest {
+	return event.NewAPIGatewayWebsocket(r, mustReadBody(r))
+}
+
+// NewAPIGatewayV2HTTPEvent returns an API gateway http v2 event for the request
+// The function panics if the request body cannot be read
+func NewAPIGatewayV2HTTPEvent(r *http.Request) *events.APIGatewayV2HTTPRequest {
+	return event.NewAPIGatewayV2HTTP(r, mustReadBody(r))
+}
+
+// NewLambdaFunctionURLEvent returns a lambda function url event for the request
+// The function panics if the request body cannot be read
+func NewLambdaFunctionURLEvent(r *http.Request) *events.LambdaFunctionURLRequest {
+	return event.NewLambdaFunctionURL(r, mustReadBody(r))
+}
+
+// NewALBTargetGroupEvent returns an alb target group event for the request
+// Multi-value headers are disabled, matching the target group default. The function panics if the request body cannot be read.
+func NewALBTargetGroupEvent(r *http.Request) *events.ALBTargetGroupRequest {
+	return event.NewALBTargetGroup(r, mustReadBody(r))
+}
+
+// NewVPCLatticeEvent returns a VPC lattice version 1.0 event for the request
+// The function panics if the request body cannot be read
+func NewVPCLatticeEvent(r *http.Request) *chop.VPCLatticeRequest {
+	return event.NewVPCLattice(r, mustReadBody(r))
+}
+
+// NewVPCLatticeV2Event returns a VPC lattice version 2.0 event for the request
+// The function panics if the request body cannot be read
+func NewVPCLatticeV2Event(r *http.Request) *chop.VPCLatticeRequestV2 {
+	return event.NewVPCLatticeV2(r, mustReadBody(r))
+}
+
+// NewCloudFrontEvent returns a CloudFront Lambda@Edge viewer request event for the request
+// The function panics if the request body cannot be read
+func NewCloudFrontEvent(r *http.Request) *chop.CloudFrontEvent {
+	return event.NewCloudFront(r, mustReadBody(r))
+}
+
+func mustReadBody(r *http.Request) event.Body {
+	b, err := event.ReadBody(r)
+	if err != nil {
+		panic("choptest: invalid request body: " + err.Error())
+	}
+
+	return b
+}
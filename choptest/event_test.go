@@ -0,0 +1,252 @@
+package choptest_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/stevecallear/chop/v2"
+	"github.com/stevecallear/chop/v2/choptest"
+)
+
+func TestNewEvent(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		info, _ := chop.GetRequestInfo(r)
+
+		w.Header().Add("X-Value", "1")
+		w.Header().Add("X-Value", "2")
+		fmt.Fprintf(w, "%s|%s %s|%s|%s|%s|%s|%s", info.Kind, r.Method, r.URL.String(), b,
+			r.Header.Get("Cookie"), strings.Join(r.Header.Values("X-Test"), ","), r.Host, r.RemoteAddr)
+	})
+
+	tests := []struct {
+		name   string
+		kind   chop.EventKind
+		path   string
+		body   string
+		header http.Header
+		err    bool
+	}{
+		{
+			name:   "should create api gateway proxy events",
+			kind:   chop.KindAPIGatewayProxy,
+			body:   "apigateway-proxy|POST /users/1?q=a%2Cb&q=c|body|c=d|a,b|example.com|192.0.2.1",
+			header: http.Header{"X-Value": {"1", "2"}},
+		},
+		{
+			name:   "should create api gateway websocket events",
+			kind:   chop.KindAPIGatewayWebsocket,
+			body:   "apigateway-websocket|POST /users/1?q=a%2Cb&q=c|body||a,b|example.com|192.0.2.1",
+			header: http.Header{"X-Value": {"1", "2"}},
+		},
+		{
+			name:   "should create api gateway http v2 events",
+			kind:   chop.KindAPIGatewayV2HTTP,
+			body:   "apigateway-v2-http|POST /users/1?q=a%2Cb&q=c|body|c=d|a,b|example.com|192.0.2.1",
+			header: http.Header{"X-Value": {"1,2"}},
+		},
+		{
+			name:   "should create lambda function url events",
+			kind:   chop.KindLambdaFunctionURL,
+			body:   "lambda-function-url|POST /users/1?q=a%2Cb&q=c|body|c=d|a,b|example.com|192.0.2.1",
+			header: http.Header{"X-Value": {"1,2"}},
+		},
+		{
+			name:   "should create alb target group events",
+			kind:   chop.KindALBTargetGroup,
+			body:   "alb-target-group|POST /users/1?q=c|body|c=d|b|example.com|192.0.2.1",
+			header: http.Header{"X-Value": {"1"}},
+		},
+		{
+			name:   "should create vpc lattice events",
+			kind:   chop.KindVPCLattice,
+			body:   "vpc-lattice|POST /users/1?q=c|body|c=d|a,b|example.com|192.0.2.1",
+			header: http.Header{"X-Value": {"1,2"}},
+		},
+		{
+			name:   "should create vpc lattice v2 events",
+			kind:   chop.KindVPCLatticeV2,
+			body:   "vpc-lattice-v2|POST /users/1?q=a%2Cb&q=c|body|c=d|a,b|example.com|192.0.2.1",
+			header: http.Header{"X-Value": {"1,2"}},
+		},
+		{
+			name:   "should create cloudfront events",
+			kind:   chop.KindCloudFront,
+			body:   "cloudfront|POST /users/1?q=a%2Cb&q=c|body|c=d|a,b|example.com|192.0.2.1",
+			header: http.Header{"X-Value": {"1", "2"}},
+		},
+		{
+			name: "should preserve escaped api gateway proxy paths",
+			kind: chop.KindAPIGatewayProxy,
+			path: "/100%25",
+			body: "apigateway-proxy|POST /100%25|body|c=d|a,b|example.com|192.0.2.1",
+		},
+		{
+			name: "should preserve escaped alb target group paths",
+			kind: chop.KindALBTargetGroup,
+			path: "/a/c%3Fd",
+			body: "alb-target-group|POST /a/c%3Fd|body|c=d|b|example.com|192.0.2.1",
+		},
+		{
+			name: "should return an error if the event kind is not supported",
+			kind: chop.KindAPIGatewayAuthorizer,
+			err:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := "/users/1?q=a%2Cb&q=c"
+			if tt.path != "" {
+				path = tt.path
+			}
+
+			req := httptest.NewRequest(http.MethodPost, path, strings.NewReader("body"))
+			req.Header.Set("Cookie", "c=d")
+			req.Header.Add("X-Test", "a")
+			req.Header.Add("X-Test", "b")
+
+			payload, err := choptest.NewEvent(tt.kind, req)
+			assertErrorExists(t, err, tt.err)
+			if err != nil {
+				return
+			}
+
+			b, err := chop.Wrap(handler).Invoke(context.Background(), payload)
+			assertErrorExists(t, err, false)
+
+			res, err := choptest.ReadResponse(tt.kind, b, req)
+			assertErrorExists(t, err, false)
+			if err != nil {
+				return
+			}
+
+			act, _ := io.ReadAll(res.Body)
+			assertDeepEqual(t, res.StatusCode, http.StatusOK)
+			assertDeepEqual(t, string(act), tt.body)
+			assertDeepEqual(t, res.Request, req)
+
+			for k, v := range tt.header {
+				assertDeepEqual(t, res.Header.Values(k), v)
+			}
+		})
+	}
+}
+
+func TestNewEvent_Builders(t *testing.T) {
+	newRequest := func() *http.Request {
+		r := httptest.NewRequest(http.MethodGet, "https://example.com/users/1?q=a", nil)
+		r.Header.Set("Cookie", "c=d")
+		r.Header.Set("User-Agent", "agent")
+
+		return r
+	}
+
+	t.Run("should create api gateway proxy events", func(t *testing.T) {
+		e := choptest.NewAPIGatewayProxyEvent(newRequest())
+
+		assertDeepEqual(t, e.PathParameters, map[string]string{"proxy": "users/1"})
+		assertDeepEqual(t, e.MultiValueQueryStringParameters, map[string][]string{"q": {"a"}})
+		assertDeepEqual(t, e.Headers["X-Forwarded-Proto"], "https")
+		assertDeepEqual(t, e.Headers["Cookie"], "c=d")
+		assertDeepEqual(t, e.RequestContext.Identity.UserAgent, "agent")
+		assertDeepEqual(t, e.RequestContext.DomainName, "example.com")
+		assertDeepEqual(t, e.RequestContext.RequestTimeEpoch > 0, true)
+	})
+
+	t.Run("should create api gateway websocket events", func(t *testing.T) {
+		e := choptest.NewAPIGatewayWebsocketEvent(newRequest())
+
+		assertDeepEqual(t, e.RequestContext.RouteKey, "users/1")
+		assertDeepEqual(t, e.RequestContext.EventType, chop.WebSocketEventMessage)
+		assertDeepEqual(t, e.RequestContext.ConnectionID != "", true)
+	})
+
+	t.Run("should create api gateway http v2 events", func(t *testing.T) {
+		e := choptest.NewAPIGatewayV2HTTPEvent(newRequest())
+
+		assertDeepEqual(t, e.RawPath, "/users/1")
+		assertDeepEqual(t, e.RawQueryString, "q=a")
+		assertDeepEqual(t, e.Cookies, []string{"c=d"})
+		assertDeepEqual(t, e.Headers["user-agent"], "agent")
+		assertDeepEqual(t, e.RequestContext.HTTP.SourceIP, "192.0.2.1")
+	})
+
+	t.Run("should create lambda function url events", func(t *testing.T) {
+		e := choptest.NewLambdaFunctionURLEvent(newRequest())
+
+		assertDeepEqual(t, strings.Contains(e.RequestContext.DomainName, ".lambda-url."), true)
+		assertDeepEqual(t, e.Headers["host"], "example.com")
+	})
+
+	t.Run("should create alb target group events", func(t *testing.T) {
+		e := choptest.NewALBTargetGroupEvent(newRequest())
+
+		assertDeepEqual(t, e.Headers["x-forwarded-for"], "192.0.2.1")
+		assertDeepEqual(t, e.MultiValueHeaders, map[string][]string(nil))
+		assertDeepEqual(t, e.RequestContext.ELB.TargetGroupArn != "", true)
+	})
+
+	t.Run("should create vpc lattice events", func(t *testing.T) {
+		e := choptest.NewVPCLatticeEvent(newRequest())
+
+		assertDeepEqual(t, e.RawPath, "/users/1")
+		assertDeepEqual(t, e.QueryStringParameters, map[string]string{"q": "a"})
+	})
+
+	t.Run("should create vpc lattice v2 events", func(t *testing.T) {
+		e := choptest.NewVPCLatticeV2Event(newRequest())
+
+		assertDeepEqual(t, e.Headers["user-agent"], []string{"agent"})
+		assertDeepEqual(t, e.RequestContext.Identity.Type, "NONE")
+		assertDeepEqual(t, e.RequestContext.ServiceARN != "", true)
+	})
+
+	t.Run("should create cloudfront events", func(t *testing.T) {
+		e := choptest.NewCloudFrontEvent(newRequest())
+		cf := e.Records[0].CF
+
+		assertDeepEqual(t, cf.Config.EventType, "viewer-request")
+		assertDeepEqual(t, cf.Request.Headers["host"], []chop.CloudFrontHeader{{Key: "Host", Value: "example.com"}})
+		assertDeepEqual(t, cf.Request.Body, (*chop.CloudFrontRequestBody)(nil))
+	})
+
+	t.Run("should use escaped paths", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/a/c%3Fd", nil)
+
+		assertDeepEqual(t, choptest.NewAPIGatewayProxyEvent(r).Path, "/a/c%3Fd")
+		assertDeepEqual(t, choptest.NewALBTargetGroupEvent(r).Path, "/a/c%3Fd")
+	})
+
+	t.Run("should encode binary bodies", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("\xff\xfe"))
+		e := choptest.NewAPIGatewayV2HTTPEvent(r)
+
+		assertDeepEqual(t, e.Body, "//4=")
+		assertDeepEqual(t, e.IsBase64Encoded, true)
+
+		b, _ := io.ReadAll(r.Body)
+		assertDeepEqual(t, string(b), "\xff\xfe")
+	})
+}
+
+func assertErrorExists(t *testing.T, act error, exp bool) {
+	if act != nil && !exp {
+		t.Errorf("got %v, expected nil", act)
+	}
+	if act == nil && exp {
+		t.Error("got nil, expected an error")
+	}
+}
+
+func assertDeepEqual(t *testing.T, act, exp interface{}) {
+	if !reflect.DeepEqual(act, exp) {
+		t.Errorf("got %v, expected %v", act, exp)
+	}
+}
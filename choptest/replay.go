@@ -0,0 +1,176 @@
+package choptest
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
+
+	"github.com/aws/aws-lambda-go/lambda"
+
+	"github.com/stevecallear/chop/v2"
+)
+
+type (
+	// ReplayResult represents the result of replaying a recorded invocation
+	ReplayResult struct {
+		// Recording is the replayed recording
+		Recording *chop.Recording
+
+		// Response is the replayed response
+		Response []byte
+
+		// Err is the replayed invocation error
+		Err error
+
+		// Diffs are the differences between the recorded and replayed invocations
+		Diffs []Diff
+	}
+
+	// Diff represents a difference between a recorded and replayed invocation
+	Diff struct {
+		Path     string
+		Recorded interface{}
+		Replayed interface{}
+	}
+)
+
+// ReadRecordings reads newline delimited JSON recordings, as written by chop.NewWriterSink
+func ReadRecordings(r io.Reader) ([]*chop.Recording, error) {
+	var recs []*chop.Recording
+
+	s := bufio.NewScanner(r)
+	s.Buffer(nil, 10*1024*1024)
+
+	for s.Scan() {
+		b := bytes.TrimSpace(s.Bytes())
+		if len(b) == 0 {
+			continue
+		}
+
+		rec := new(chop.Recording)
+		if err := json.Unmarshal(b, rec); err != nil {
+			return nil, err
+		}
+
+		recs = append(recs, rec)
+	}
+
+	return recs, s.Err()
+}
+
+// Replay invokes the lambda handler with each recorded payload and compares the responses
+// Recorded values that are equal to chop.RedactedValue match any replayed value
+func Replay(ctx context.Context, h lambda.Handler, recs []*chop.Recording) []ReplayResult {
+	res := make([]ReplayResult, 0, len(recs))
+	for _, rec := range recs {
+		b, err := h.Invoke(ctx, recordedPayload(rec.Payload))
+
+		rr := ReplayResult{
+			Recording: rec,
+			Response:  b,
+			Err:       err,
+		}
+
+		var errMsg string
+		if err != nil {
+			errMsg = err.Error()
+		}
+
+		if rec.Error != errMsg {
+			rr.Diffs = append(rr.Diffs, Diff{Path: "error", Recorded: rec.Error, Replayed: errMsg})
+		}
+
+		rr.Diffs = append(rr.Diffs, diffValues("response", decodeValue(rec.Response), decodeValue(b))...)
+		res = append(res, rr)
+	}
+
+	return res
+}
+
+// String returns a description of the diff
+func (d Diff) String() string {
+	return fmt.Sprintf("%s: recorded %v, replayed %v", d.Path, d.Recorded, d.Replayed)
+}
+
+// recordedPayload returns the recorded payload, unwrapping invalid payloads that were recorded as strings
+func recordedPayload(b json.RawMessage) []byte {
+	var s string
+	if err := json.Unmarshal(b, &s); err == nil {
+		return []byte(s)
+	}
+
+	return b
+}
+
+func decodeValue(b []byte) interface{} {
+	if len(b) == 0 {
+		return nil
+	}
+
+	d := json.NewDecoder(bytes.NewReader(b))
+	d.UseNumber()
+
+	var v interface{}
+	if err := d.Decode(&v); err != nil {
+		return string(b)
+	}
+
+	return v
+}
+
+func diffValues(path string, recorded, replayed interface{}) []Diff {
+	if recorded == chop.RedactedValue {
+		return nil
+	}
+
+	switch rec := recorded.(type) {
+	case map[string]interface{}:
+		rep, ok := replayed.(map[string]interface{})
+		if !ok {
+			break
+		}
+
+		keys := make([]string, 0, len(rec)+len(rep))
+		for k := range rec {
+			keys = append(keys, k)
+		}
+		for k := range rep {
+			if _, ok := rec[k]; !ok {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+
+		var ds []Diff
+		for _, k := range keys {
+			ds = append(ds, diffValues(path+"."+k, rec[k], rep[k])...)
+		}
+
+		return ds
+
+	case []interface{}:
+		rep, ok := replayed.([]interface{})
+		if !ok || len(rec) != len(rep) {
+			break
+		}
+
+		var ds []Diff
+		for i := range rec {
+			ds = append(ds, diffValues(path+"."+strconv.Itoa(i), rec[i], rep[i])...)
+		}
+
+		return ds
+	}
+
+	if reflect.DeepEqual(recorded, replayed) {
+		return nil
+	}
+
+	return []Diff{{Path: path, Recorded: recorded, Replayed: replayed}}
+}
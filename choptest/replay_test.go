@@ -0,0 +1,125 @@
+package choptest_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stevecallear/chop/v2"
+	"github.com/stevecallear/chop/v2/choptest"
+)
+
+func TestReplay(t *testing.T) {
+	newHandler := func(version string) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("X-Version", version)
+			w.Header().Set("X-Token", version)
+			fmt.Fprintf(w, "%s %s", r.Method, r.URL.Path)
+		})
+	}
+
+	tests := []struct {
+		name     string
+		path     string
+		rules    []chop.RedactionRule
+		replayed http.Handler
+		exp      []string
+	}{
+		{
+			name:     "should return no diffs if the responses match",
+			path:     "/users/1",
+			replayed: newHandler("1"),
+		},
+		{
+			name:     "should return diffs if the responses do not match",
+			path:     "/users/1",
+			replayed: newHandler("2"),
+			exp: []string{
+				"response.headers.X-Token: recorded 1, replayed 2",
+				"response.headers.X-Version: recorded 1, replayed 2",
+				"response.multiValueHeaders.X-Token.0: recorded 1, replayed 2",
+				"response.multiValueHeaders.X-Version.0: recorded 1, replayed 2",
+			},
+		},
+		{
+			name:     "should ignore redacted values",
+			path:     "/users/1",
+			rules:    []chop.RedactionRule{chop.RedactHeaders("X-Token")},
+			replayed: newHandler("2"),
+			exp: []string{
+				"response.headers.X-Version: recorded 1, replayed 2",
+				"response.multiValueHeaders.X-Version.0: recorded 1, replayed 2",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sink := chop.NewMemorySink()
+			h := chop.Wrap(newHandler("1"), chop.WithRecorder(sink, tt.rules...))
+
+			r := httptest.NewRequest(http.MethodPost, tt.path, nil)
+			payload, _ := choptest.NewEvent(chop.KindAPIGatewayProxy, r)
+			h.Invoke(context.Background(), payload)
+
+			res := choptest.Replay(context.Background(), chop.Wrap(tt.replayed), sink.Recordings())
+			assertDeepEqual(t, len(res), 1)
+
+			var act []string
+			for _, d := range res[0].Diffs {
+				act = append(act, d.String())
+			}
+
+			assertDeepEqual(t, act, tt.exp)
+		})
+	}
+}
+
+func TestReplay_Errors(t *testing.T) {
+	t.Run("should return diffs if the errors do not match", func(t *testing.T) {
+		sink := chop.NewMemorySink()
+		chop.Wrap(http.NotFoundHandler(), chop.WithRecorder(sink)).Invoke(context.Background(), []byte(`{}`))
+
+		h := invokerFunc(func(context.Context, []byte) ([]byte, error) {
+			return []byte(`{"statusCode":200}`), nil
+		})
+
+		res := choptest.Replay(context.Background(), h, sink.Recordings())
+		assertDeepEqual(t, len(res), 1)
+		assertDeepEqual(t, res[0].Diffs, []choptest.Diff{
+			{Path: "error", Recorded: chop.ErrUnsupportedEventType.Error(), Replayed: ""},
+			{Path: "response", Recorded: nil, Replayed: map[string]interface{}{"statusCode": json.Number("200")}},
+		})
+	})
+}
+
+func TestReadRecordings(t *testing.T) {
+	t.Run("should read recordings written by the writer sink", func(t *testing.T) {
+		b := new(bytes.Buffer)
+		h := chop.Wrap(http.NotFoundHandler(), chop.WithRecorder(chop.NewWriterSink(b)))
+
+		for _, p := range []string{"/a", "/b"} {
+			payload, _ := choptest.NewEvent(chop.KindAPIGatewayV2HTTP, httptest.NewRequest(http.MethodGet, p, nil))
+			h.Invoke(context.Background(), payload)
+		}
+		h.Invoke(context.Background(), []byte("invalid"))
+
+		recs, err := choptest.ReadRecordings(b)
+		assertErrorExists(t, err, false)
+		assertDeepEqual(t, len(recs), 3)
+
+		for _, rr := range choptest.Replay(context.Background(), h, recs) {
+			assertDeepEqual(t, rr.Diffs, []choptest.Diff(nil))
+		}
+	})
+
+	t.Run("should return an error if a recording is invalid", func(t *testing.T) {
+		_, err := choptest.ReadRecordings(strings.NewReader("{}\ninvalid\n"))
+		assertErrorExists(t, err, true)
+	})
+}
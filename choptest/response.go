@@ -0,0 +1,24 @@
+package choptest
+
+import (
+	"net/http"
+
+	"github.com/stevecallear/chop/v2"
+	"github.com/stevecallear/chop/v2/internal/event"
+)
+
+// ErrUnsupportedResponse indicates that the lambda response payload is not a supported HTTP response
+var ErrUnsupportedResponse = event.ErrUnsupportedResponse
+
+// ReadResponse converts the lambda response payload for the specified event kind into an HTTP response
+// Only the response fields honoured by the integration for the event kind are used, for example
+// multi-value headers are ignored for API gateway http v2 responses. The request is optional and is set as the response request.
+func ReadResponse(kind chop.EventKind, payload []byte, req *http.Request) (*http.Response, error) {
+	res, err := event.ReadResponse(string(kind), payload)
+	if err != nil {
+		return nil, err
+	}
+
+	res.Request = req
+	return res, nil
+}
@@ -0,0 +1,139 @@
+package choptest_test
+
+import (
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/stevecallear/chop/v2"
+	"github.com/stevecallear/chop/v2/choptest"
+)
+
+func TestReadResponse(t *testing.T) {
+	tests := []struct {
+		name    string
+		kind    chop.EventKind
+		payload string
+		status  string
+		code    int
+		header  http.Header
+		body    string
+		err     bool
+	}{
+		{
+			name:    "should read single value responses",
+			kind:    chop.KindAPIGatewayProxy,
+			payload: `{"statusCode":201,"headers":{"Content-Type":"text/plain"},"body":"body"}`,
+			status:  "201 Created",
+			code:    http.StatusCreated,
+			header:  http.Header{"Content-Type": {"text/plain"}},
+			body:    "body",
+		},
+		{
+			name:    "should merge api gateway proxy multi-value headers",
+			kind:    chop.KindAPIGatewayProxy,
+			payload: `{"statusCode":200,"headers":{"X-Value":"2","X-Other":"a"},"multiValueHeaders":{"X-Value":["1","2"]},"body":"body"}`,
+			status:  "200 OK",
+			code:    http.StatusOK,
+			header:  http.Header{"X-Value": {"1", "2"}, "X-Other": {"a"}},
+			body:    "body",
+		},
+		{
+			name:    "should ignore api gateway http v2 multi-value headers",
+			kind:    chop.KindAPIGatewayV2HTTP,
+			payload: `{"statusCode":200,"headers":{"X-Value":"1,2"},"multiValueHeaders":{"X-Value":["1","2"]},"cookies":["a=b","c=d"]}`,
+			status:  "200 OK",
+			code:    http.StatusOK,
+			header:  http.Header{"X-Value": {"1,2"}, "Set-Cookie": {"a=b", "c=d"}},
+		},
+		{
+			name:    "should ignore alb target group multi-value headers",
+			kind:    chop.KindALBTargetGroup,
+			payload: `{"statusCode":200,"statusDescription":"200 OK","headers":{"X-Value":"1"},"multiValueHeaders":{"X-Value":["1","2"]}}`,
+			status:  "200 OK",
+			code:    http.StatusOK,
+			header:  http.Header{"X-Value": {"1"}},
+		},
+		{
+			name:    "should ignore api gateway proxy cookies",
+			kind:    chop.KindAPIGatewayProxy,
+			payload: `{"statusCode":200,"cookies":["a=b"]}`,
+			status:  "200 OK",
+			code:    http.StatusOK,
+			header:  http.Header{},
+		},
+		{
+			name:    "should decode base64 bodies",
+			kind:    chop.KindLambdaFunctionURL,
+			payload: `{"statusCode":200,"body":"//4=","isBase64Encoded":true}`,
+			status:  "200 OK",
+			code:    http.StatusOK,
+			header:  http.Header{},
+			body:    "\xff\xfe",
+		},
+		{
+			name:    "should use the status description",
+			kind:    chop.KindVPCLattice,
+			payload: `{"statusCode":404,"statusDescription":"404 Not Found","body":""}`,
+			status:  "404 Not Found",
+			code:    http.StatusNotFound,
+			header:  http.Header{},
+		},
+		{
+			name:    "should read cloudfront responses",
+			kind:    chop.KindCloudFront,
+			payload: `{"status":"200","statusDescription":"OK","headers":{"x-value":[{"key":"X-Value","value":"1"},{"value":"2"}]},"body":"//4=","bodyEncoding":"base64"}`,
+			status:  "200 OK",
+			code:    http.StatusOK,
+			header:  http.Header{"X-Value": {"1", "2"}},
+			body:    "\xff\xfe",
+		},
+		{
+			name:    "should return an error if the cloudfront status is invalid",
+			kind:    chop.KindCloudFront,
+			payload: `{"status":"invalid"}`,
+			err:     true,
+		},
+		{
+			name:    "should return an error if the body encoding is invalid",
+			kind:    chop.KindAPIGatewayProxy,
+			payload: `{"statusCode":200,"body":"invalid","isBase64Encoded":true}`,
+			err:     true,
+		},
+		{
+			name:    "should return an error if the payload is invalid",
+			kind:    chop.KindAPIGatewayProxy,
+			payload: `{"statusCode":"invalid"}`,
+			err:     true,
+		},
+		{
+			name:    "should return an error if the response is not supported",
+			kind:    chop.KindCloudFront,
+			payload: `{"method":"GET","uri":"/"}`,
+			err:     true,
+		},
+		{
+			name:    "should return an error if the event kind is not supported",
+			kind:    chop.KindAPIGatewayAuthorizer,
+			payload: `{"statusCode":200}`,
+			err:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := choptest.ReadResponse(tt.kind, []byte(tt.payload), nil)
+			assertErrorExists(t, err, tt.err)
+			if err != nil {
+				return
+			}
+
+			b, _ := io.ReadAll(res.Body)
+			assertDeepEqual(t, res.Status, tt.status)
+			assertDeepEqual(t, res.StatusCode, tt.code)
+			assertDeepEqual(t, res.Header, tt.header)
+			assertDeepEqual(t, string(b), tt.body)
+			assertDeepEqual(t, res.ContentLength, int64(len(tt.body)))
+		})
+	}
+}
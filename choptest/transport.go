@@ -0,0 +1,51 @@
+package choptest
+
+import (
+	"net/http"
+
+	"github.com/aws/aws-lambda-go/lambda"
+
+	"github.com/stevecallear/chop/v2"
+)
+
+// Transport represents an HTTP round tripper that converts requests into lambda events of the specified kind
+// and invokes the lambda handler in-process, allowing HTTP clients to be tested without a network
+type Transport struct {
+	handler lambda.Handler
+	kind    chop.EventKind
+}
+
+// NewTransport returns a new transport for the specified lambda handler and event kind
+func NewTransport(h lambda.Handler, kind chop.EventKind) *Transport {
+	return &Transport{
+		handler: h,
+		kind:    kind,
+	}
+}
+
+// NewClient returns a new HTTP client that uses a transport for the specified lambda handler and event kind
+func NewClient(h lambda.Handler, kind chop.EventKind) *http.Client {
+	return &http.Client{
+		Transport: NewTransport(h, kind),
+	}
+}
+
+// RoundTrip converts the request into a lambda event, invokes the lambda handler and returns the decoded response
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	r := req.Clone(req.Context())
+	if r.Host == "" {
+		r.Host = r.URL.Host
+	}
+
+	payload, err := NewEvent(t.kind, r)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := t.handler.Invoke(req.Context(), payload)
+	if err != nil {
+		return nil, err
+	}
+
+	return ReadResponse(t.kind, b, req)
+}
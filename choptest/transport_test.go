@@ -0,0 +1,165 @@
+package choptest_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/lambda"
+
+	"github.com/stevecallear/chop/v2"
+	"github.com/stevecallear/chop/v2/choptest"
+)
+
+type invokerFunc func(context.Context, []byte) ([]byte, error)
+
+func (fn invokerFunc) Invoke(ctx context.Context, payload []byte) ([]byte, error) {
+	return fn(ctx, payload)
+}
+
+func TestTransport_RoundTrip(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/binary" {
+			w.Header().Set("Content-Type", "application/octet-stream")
+			w.Write([]byte{0xff, 0xfe})
+			return
+		}
+
+		b, _ := io.ReadAll(r.Body)
+		info, _ := chop.GetRequestInfo(r)
+
+		http.SetCookie(w, &http.Cookie{Name: "a", Value: "b"})
+		w.Header().Add("X-Value", "1")
+		w.Header().Add("X-Value", "2")
+		fmt.Fprintf(w, "%s|%s %s|%s|%s|%s", info.Kind, r.Method, r.URL.String(), b, r.Header.Get("Authorization"), r.Host)
+	})
+
+	tests := []struct {
+		name    string
+		handler lambda.Handler
+		kind    chop.EventKind
+		path    string
+		body    string
+		header  http.Header
+		err     bool
+	}{
+		{
+			name:   "should round trip api gateway proxy events",
+			kind:   chop.KindAPIGatewayProxy,
+			path:   "/users/1?q=a",
+			body:   "apigateway-proxy|POST /users/1?q=a|body|token|example.com",
+			header: http.Header{"Set-Cookie": {"a=b"}, "X-Value": {"1", "2"}},
+		},
+		{
+			name:   "should round trip api gateway http v2 events",
+			kind:   chop.KindAPIGatewayV2HTTP,
+			path:   "/users/1?q=a",
+			body:   "apigateway-v2-http|POST /users/1?q=a|body|token|example.com",
+			header: http.Header{"Set-Cookie": {"a=b"}, "X-Value": {"1,2"}},
+		},
+		{
+			name:   "should round trip lambda function url events",
+			kind:   chop.KindLambdaFunctionURL,
+			path:   "/users/1?q=a",
+			body:   "lambda-function-url|POST /users/1?q=a|body|token|example.com",
+			header: http.Header{"Set-Cookie": {"a=b"}, "X-Value": {"1,2"}},
+		},
+		{
+			name:   "should round trip alb target group events",
+			kind:   chop.KindALBTargetGroup,
+			path:   "/users/1?q=a",
+			body:   "alb-target-group|POST /users/1?q=a|body|token|example.com",
+			header: http.Header{"Set-Cookie": {"a=b"}, "X-Value": {"1"}},
+		},
+		{
+			name:   "should decode base64 response bodies",
+			kind:   chop.KindAPIGatewayV2HTTP,
+			path:   "/binary",
+			body:   "\xff\xfe",
+			header: http.Header{"Content-Type": {"application/octet-stream"}},
+		},
+		{
+			name: "should return invocation errors",
+			handler: invokerFunc(func(context.Context, []byte) ([]byte, error) {
+				return nil, errors.New("error")
+			}),
+			kind: chop.KindAPIGatewayV2HTTP,
+			path: "/",
+			err:  true,
+		},
+		{
+			name: "should return an error if the event kind is not supported",
+			kind: chop.KindAPIGatewayAuthorizer,
+			path: "/",
+			err:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var h lambda.Handler = chop.Wrap(handler)
+			if tt.handler != nil {
+				h = tt.handler
+			}
+
+			req, _ := http.NewRequest(http.MethodPost, "https://example.com"+tt.path, strings.NewReader("body"))
+			req.Header.Set("Authorization", "token")
+
+			res, err := choptest.NewClient(h, tt.kind).Do(req)
+			assertErrorExists(t, err, tt.err)
+			if err != nil {
+				return
+			}
+			defer res.Body.Close()
+
+			b, _ := io.ReadAll(res.Body)
+			assertDeepEqual(t, res.StatusCode, http.StatusOK)
+			assertDeepEqual(t, string(b), tt.body)
+
+			for k, v := range tt.header {
+				assertDeepEqual(t, res.Header.Values(k), v)
+			}
+		})
+	}
+}
+
+func TestTransport_RoundTrip_Client(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/login":
+			http.SetCookie(w, &http.Cookie{Name: "session", Value: "abc", Path: "/"})
+			http.Redirect(w, r, "/profile", http.StatusFound)
+		case "/profile":
+			c, err := r.Cookie("session")
+			if err != nil {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			fmt.Fprint(w, c.Value)
+		}
+	})
+
+	jar, _ := cookiejar.New(nil)
+	c := choptest.NewClient(chop.Wrap(handler), chop.KindAPIGatewayV2HTTP)
+	c.Jar = jar
+
+	t.Run("should support redirects and cookies", func(t *testing.T) {
+		res, err := c.PostForm("https://example.com/login", url.Values{"user": {"a"}})
+		assertErrorExists(t, err, false)
+		if err != nil {
+			return
+		}
+		defer res.Body.Close()
+
+		b, _ := io.ReadAll(res.Body)
+		assertDeepEqual(t, res.StatusCode, http.StatusOK)
+		assertDeepEqual(t, res.Request.URL.Path, "/profile")
+		assertDeepEqual(t, string(b), "abc")
+	})
+}
@@ -0,0 +1,117 @@
+package chop
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/stevecallear/chop/v2/internal/event"
+)
+
+type (
+	// CloudFrontEvent represents a CloudFront Lambda@Edge event
+	CloudFrontEvent = event.CloudFrontEvent
+
+	// CloudFrontEventRecord represents a CloudFront Lambda@Edge event record
+	CloudFrontEventRecord = event.CloudFrontEventRecord
+
+	// CloudFrontEventCF represents the CloudFront data of a Lambda@Edge event record
+	CloudFrontEventCF = event.CloudFrontEventCF
+
+	// CloudFrontConfig represents the CloudFront distribution configuration of a Lambda@Edge event
+	CloudFrontConfig = event.CloudFrontConfig
+
+	// CloudFrontRequest represents a CloudFront Lambda@Edge request
+	CloudFrontRequest = event.CloudFrontRequest
+
+	// CloudFrontRequestBody represents the body of a CloudFront Lambda@Edge request
+	CloudFrontRequestBody = event.CloudFrontRequestBody
+
+	// CloudFrontResponse represents a CloudFront Lambda@Edge generated response
+	CloudFrontResponse = event.CloudFrontResponse
+
+	// CloudFrontHeaders represents CloudFront headers, keyed by lower case header name
+	CloudFrontHeaders = event.CloudFrontHeaders
+
+	// CloudFrontHeader represents a CloudFront header value
+	CloudFrontHeader = event.CloudFrontHeader
+
+	responseWriterContextKey struct{}
+)
+
+// ForwardRequest marks the specified request to be forwarded rather than returning the handler response
+// Changes to the request method, URL and headers are included in the forwarded request. Forwarding is only
+// supported for CloudFront Lambda@Edge request events, and is ignored for other event types.
+func ForwardRequest(r *http.Request) {
+	if w, ok := r.Context().Value(responseWriterContextKey{}).(*ResponseWriter); ok {
+		w.forward = r
+	}
+}
+
+func withResponseWriter(r *http.Request, w *ResponseWriter) *http.Request {
+	ctx := context.WithValue(r.Context(), responseWriterContextKey{}, w)
+
+	return r.WithContext(ctx)
+}
+
+func newCloudFrontRequest(r *http.Request) *CloudFrontRequest {
+	var cr CloudFrontRequest
+	if e, ok := GetEventAs[*CloudFrontEvent](r); ok && len(e.Records) > 0 {
+		cr = e.Records[0].CF.Request
+	}
+
+	h := r.Header.Clone()
+	if r.Host != "" {
+		h.Set("Host", r.Host)
+	}
+
+	cr.Method = r.Method
+	cr.URI = r.URL.EscapedPath()
+	cr.QueryString = r.URL.RawQuery
+	cr.Headers = toCloudFrontHeaders(h)
+
+	return &cr
+}
+
+func newCloudFrontResponse(w *ResponseWriter) *CloudFrontResponse {
+	body, isBase64Encoded := w.EncodedBody()
+
+	encoding := "text"
+	if isBase64Encoded {
+		encoding = "base64"
+	}
+
+	return &CloudFrontResponse{
+		Status:            strconv.Itoa(w.StatusCode()),
+		StatusDescription: http.StatusText(w.StatusCode()),
+		Headers:           toCloudFrontHeaders(w.Header()),
+		Body:              body,
+		BodyEncoding:      encoding,
+	}
+}
+
+func addCloudFrontHeaders(ch CloudFrontHeaders, h http.Header) {
+	for k, vs := range ch {
+		for _, v := range vs {
+			key := v.Key
+			if key == "" {
+				key = k
+			}
+
+			h.Add(key, v.Value)
+		}
+	}
+}
+
+func toCloudFrontHeaders(h http.Header) CloudFrontHeaders {
+	ch := make(CloudFrontHeaders, len(h))
+	for k, vs := range h {
+		lk := strings.ToLower(k)
+		for _, v := range vs {
+			ch[lk] = append(ch[lk], CloudFrontHeader{Key: k, Value: v})
+		}
+	}
+
+	return ch
+}
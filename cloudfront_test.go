@@ -0,0 +1,178 @@
+package chop_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stevecallear/chop/v2"
+)
+
+func TestHandler_Invoke_CloudFront(t *testing.T) {
+	const payload = `{"Records":[{"cf":{"config":{"distributionDomainName":"d111111abcdef8.cloudfront.net","distributionId":"EDFDVBD6EXAMPLE","eventType":"origin-request","requestId":"requestid"},"request":{"clientIp":"203.0.113.178","headers":{"host":[{"key":"Host","value":"www.example.com"}],"user-agent":[{"key":"User-Agent","value":"agent"}],"accept":[{"key":"Accept","value":"text/html"},{"key":"Accept","value":"*/*"}]},"method":"POST","querystring":"a=b","uri":"/users/1","body":{"inputTruncated":false,"action":"read-only","encoding":"base64","data":"Ym9keQ=="},"origin":{"custom":{"domainName":"origin.example.com"}}}}}]}`
+
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		exp     request
+		act     interface{}
+		res     interface{}
+	}{
+		{
+			name: "should return generated responses",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Cache-Control", "no-cache")
+				w.Write([]byte("generated"))
+			},
+			exp: request{
+				method: http.MethodPost,
+				url:    "/users/1?a=b",
+				body:   "body",
+				header: http.Header{
+					"User-Agent": {"agent"},
+					"Accept":     {"text/html", "*/*"},
+				},
+			},
+			act: new(chop.CloudFrontResponse),
+			res: &chop.CloudFrontResponse{
+				Status:            "200",
+				StatusDescription: "OK",
+				Headers: chop.CloudFrontHeaders{
+					"cache-control": {{Key: "Cache-Control", Value: "no-cache"}},
+					"content-type":  {{Key: "Content-Type", Value: "text/plain; charset=utf-8"}},
+				},
+				Body:         "generated",
+				BodyEncoding: "text",
+			},
+		},
+		{
+			name: "should return binary generated responses",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/octet-stream")
+				w.Write([]byte{0xff})
+			},
+			exp: request{
+				method: http.MethodPost,
+				url:    "/users/1?a=b",
+				body:   "body",
+				header: http.Header{
+					"User-Agent": {"agent"},
+					"Accept":     {"text/html", "*/*"},
+				},
+			},
+			act: new(chop.CloudFrontResponse),
+			res: &chop.CloudFrontResponse{
+				Status:            "200",
+				StatusDescription: "OK",
+				Headers: chop.CloudFrontHeaders{
+					"content-type": {{Key: "Content-Type", Value: "application/octet-stream"}},
+				},
+				Body:         "/w==",
+				BodyEncoding: "base64",
+			},
+		},
+		{
+			name: "should forward modified requests",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				r = r.Clone(r.Context())
+				r.URL.Path = "/v2" + r.URL.Path
+				r.Header.Del("Accept")
+				r.Header.Set("X-Tenant", "t1")
+				chop.ForwardRequest(r)
+			},
+			exp: request{
+				method: http.MethodPost,
+				url:    "/users/1?a=b",
+				body:   "body",
+				header: http.Header{
+					"User-Agent": {"agent"},
+					"Accept":     {"text/html", "*/*"},
+				},
+			},
+			act: new(chop.CloudFrontRequest),
+			res: &chop.CloudFrontRequest{
+				ClientIP: "203.0.113.178",
+				Headers: chop.CloudFrontHeaders{
+					"host":       {{Key: "Host", Value: "www.example.com"}},
+					"user-agent": {{Key: "User-Agent", Value: "agent"}},
+					"x-tenant":   {{Key: "X-Tenant", Value: "t1"}},
+				},
+				Method:      http.MethodPost,
+				QueryString: "a=b",
+				URI:         "/v2/users/1",
+				Body: &chop.CloudFrontRequestBody{
+					Action:   "read-only",
+					Encoding: "base64",
+					Data:     "Ym9keQ==",
+				},
+				Origin: map[string]interface{}{
+					"custom": map[string]interface{}{"domainName": "origin.example.com"},
+				},
+			},
+		},
+		{
+			name: "should not forward requests if the handler panics",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				chop.ForwardRequest(r)
+				panic("error")
+			},
+			exp: request{
+				method: http.MethodPost,
+				url:    "/users/1?a=b",
+				body:   "body",
+				header: http.Header{
+					"User-Agent": {"agent"},
+					"Accept":     {"text/html", "*/*"},
+				},
+			},
+			act: new(chop.CloudFrontResponse),
+			res: &chop.CloudFrontResponse{
+				Status:            "500",
+				StatusDescription: "Internal Server Error",
+				Headers: chop.CloudFrontHeaders{
+					"content-type":           {{Key: "Content-Type", Value: "text/plain; charset=utf-8"}},
+					"x-content-type-options": {{Key: "X-Content-Type-Options", Value: "nosniff"}},
+				},
+				Body:         "Internal Server Error\n",
+				BodyEncoding: "text",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			captureLog(t)
+
+			var req request
+			var info chop.RequestInfo
+			h := chop.Wrap(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				req = toRequest(r)
+				info, _ = chop.GetRequestInfo(r)
+				tt.handler(w, r)
+			}))
+
+			b, err := h.Invoke(context.Background(), []byte(payload))
+			assertErrorExists(t, err, false)
+			assertDeepEqual(t, req, tt.exp)
+			assertDeepEqual(t, info, chop.RequestInfo{
+				Kind:       chop.KindCloudFront,
+				RequestID:  "requestid",
+				SourceIP:   "203.0.113.178",
+				UserAgent:  "agent",
+				DomainName: "d111111abcdef8.cloudfront.net",
+			})
+
+			err = json.Unmarshal(b, tt.act)
+			assertErrorExists(t, err, false)
+			assertDeepEqual(t, tt.act, tt.res)
+		})
+	}
+}
+
+func TestHandler_Invoke_CloudFrontResponseEvents(t *testing.T) {
+	h := chop.Wrap(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	_, err := h.Invoke(context.Background(), []byte(`{"Records":[{"cf":{"config":{"eventType":"origin-response"},"request":{"method":"GET","uri":"/"},"response":{"status":"200"}}}]}`))
+	assertDeepEqual(t, err, chop.ErrUnsupportedEventType)
+}
@@ -0,0 +1,111 @@
+// Command chop-emulator converts HTTP requests into lambda events and invokes a locally running lambda function
+//
+// The function can be invoked using either the go1.x RPC API, by running the function with the
+// _LAMBDA_SERVER_PORT environment variable, or the Lambda runtime interface emulator invocation URL.
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/rpc"
+	"time"
+
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/aws/aws-lambda-go/lambda/messages"
+	"github.com/tidwall/gjson"
+
+	"github.com/stevecallear/chop/v2"
+)
+
+type (
+	rpcInvoker struct {
+		addr string
+	}
+
+	httpInvoker struct {
+		url string
+	}
+)
+
+func main() {
+	addr := flag.String("addr", ":8080", "the address to listen on")
+	event := flag.String("event", string(chop.KindAPIGatewayV2HTTP), "the event kind: apigateway-proxy, apigateway-websocket, apigateway-v2-http, lambda-function-url, alb-target-group, vpc-lattice, vpc-lattice-v2 or cloudfront")
+	rpcAddr := flag.String("rpc", "", "the RPC address of a function started with _LAMBDA_SERVER_PORT, e.g. localhost:8001")
+	url := flag.String("url", "http://localhost:9000/2015-03-31/functions/function/invocations", "the runtime interface emulator invocation URL")
+	flag.Parse()
+
+	var h lambda.Handler = &httpInvoker{url: *url}
+	if *rpcAddr != "" {
+		h = &rpcInvoker{addr: *rpcAddr}
+	}
+
+	log.Printf("chop-emulator: serving %s events on %s", *event, *addr)
+	log.Fatal(http.ListenAndServe(*addr, chop.NewEmulator(h, chop.EventKind(*event))))
+}
+
+func (i *rpcInvoker) Invoke(ctx context.Context, payload []byte) ([]byte, error) {
+	c, err := rpc.Dial("tcp", i.addr)
+	if err != nil {
+		return nil, err
+	}
+	defer c.Close()
+
+	d := time.Now().Add(15 * time.Minute)
+	if cd, ok := ctx.Deadline(); ok {
+		d = cd
+	}
+
+	req := &messages.InvokeRequest{
+		Payload:   payload,
+		RequestId: fmt.Sprintf("%d", time.Now().UnixNano()),
+		Deadline: messages.InvokeRequest_Timestamp{
+			Seconds: d.Unix(),
+			Nanos:   int64(d.Nanosecond()),
+		},
+	}
+
+	res := new(messages.InvokeResponse)
+	if err = c.Call("Function.Invoke", req, res); err != nil {
+		return nil, err
+	}
+
+	if res.Error != nil {
+		return nil, errors.New(res.Error.Message)
+	}
+
+	return res.Payload, nil
+}
+
+func (i *httpInvoker) Invoke(ctx context.Context, payload []byte) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, i.url, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	b, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected invocation status: %s", res.Status)
+	}
+
+	if m := gjson.GetBytes(b, "errorMessage"); m.Exists() {
+		return nil, errors.New(m.String())
+	}
+
+	return b, nil
+}
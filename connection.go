@@ -0,0 +1,200 @@
+package chop
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+type (
+	// ConnectionClient represents a client for the API gateway websocket @connections API
+	ConnectionClient interface {
+		// PostToConnection sends the specified data to the connection
+		PostToConnection(ctx context.Context, connectionID string, data []byte) error
+
+		// DeleteConnection closes the connection
+		DeleteConnection(ctx context.Context, connectionID string) error
+	}
+
+	// MemoryConnectionClient represents an in-memory connection client for local development and tests
+	MemoryConnectionClient struct {
+		mu       sync.Mutex
+		messages map[string][][]byte
+		deleted  map[string]bool
+	}
+
+	httpConnectionClient struct {
+		endpoint string
+		region   string
+		client   *http.Client
+	}
+)
+
+// ErrConnectionGone indicates that the websocket connection no longer exists
+var ErrConnectionGone = errors.New("websocket connection gone")
+
+// NewConnectionClient returns a new connection client for the specified endpoint, e.g. WebSocketConnection.Endpoint
+// Requests are signed using the credentials and region in the lambda environment
+func NewConnectionClient(endpoint string) ConnectionClient {
+	return &httpConnectionClient{
+		endpoint: strings.TrimSuffix(endpoint, "/"),
+		region:   os.Getenv("AWS_REGION"),
+		client:   http.DefaultClient,
+	}
+}
+
+func (c *httpConnectionClient) PostToConnection(ctx context.Context, connectionID string, data []byte) error {
+	return c.do(ctx, http.MethodPost, connectionID, data)
+}
+
+func (c *httpConnectionClient) DeleteConnection(ctx context.Context, connectionID string) error {
+	return c.do(ctx, http.MethodDelete, connectionID, nil)
+}
+
+func (c *httpConnectionClient) do(ctx context.Context, method, connectionID string, data []byte) error {
+	u := c.endpoint + "/@connections/" + awsEscape(connectionID, true)
+
+	r, err := http.NewRequestWithContext(ctx, method, u, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+
+	signRequest(r, data, c.region, "execute-api", time.Now())
+
+	res, err := c.client.Do(r)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	switch {
+	case res.StatusCode == http.StatusGone:
+		return ErrConnectionGone
+	case res.StatusCode < 200 || res.StatusCode > 299:
+		return fmt.Errorf("unexpected connections api status: %s", res.Status)
+	}
+
+	return nil
+}
+
+// NewMemoryConnectionClient returns a new in-memory connection client
+func NewMemoryConnectionClient() *MemoryConnectionClient {
+	return &MemoryConnectionClient{
+		messages: map[string][][]byte{},
+		deleted:  map[string]bool{},
+	}
+}
+
+// PostToConnection records the specified data for the connection
+// ErrConnectionGone is returned if the connection has been deleted
+func (c *MemoryConnectionClient) PostToConnection(ctx context.Context, connectionID string, data []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.deleted[connectionID] {
+		return ErrConnectionGone
+	}
+
+	c.messages[connectionID] = append(c.messages[connectionID], append([]byte(nil), data...))
+	return nil
+}
+
+// DeleteConnection marks the connection as deleted
+// ErrConnectionGone is returned if the connection has already been deleted
+func (c *MemoryConnectionClient) DeleteConnection(ctx context.Context, connectionID string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.deleted[connectionID] {
+		return ErrConnectionGone
+	}
+
+	c.deleted[connectionID] = true
+	return nil
+}
+
+// Messages returns the data posted to the specified connection
+func (c *MemoryConnectionClient) Messages(connectionID string) [][]byte {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return append([][]byte(nil), c.messages[connectionID]...)
+}
+
+// signRequest signs the request using AWS signature version 4 if credentials exist in the environment
+func signRequest(r *http.Request, body []byte, region, service string, t time.Time) {
+	id, secret := os.Getenv("AWS_ACCESS_KEY_ID"), os.Getenv("AWS_SECRET_ACCESS_KEY")
+	if id == "" || secret == "" {
+		return
+	}
+
+	ts := t.UTC().Format("20060102T150405Z")
+	scope := ts[:8] + "/" + region + "/" + service + "/aws4_request"
+
+	r.Header.Set("X-Amz-Date", ts)
+
+	headers := []string{"host:" + r.URL.Host, "x-amz-date:" + ts}
+	signed := "host;x-amz-date"
+
+	if token := os.Getenv("AWS_SESSION_TOKEN"); token != "" {
+		r.Header.Set("X-Amz-Security-Token", token)
+		headers = append(headers, "x-amz-security-token:"+token)
+		signed += ";x-amz-security-token"
+	}
+
+	creq := strings.Join([]string{
+		r.Method,
+		awsEscape(r.URL.EscapedPath(), false),
+		r.URL.RawQuery,
+		strings.Join(headers, "\n") + "\n",
+		signed,
+		hashHex(body),
+	}, "\n")
+
+	sts := strings.Join([]string{"AWS4-HMAC-SHA256", ts, scope, hashHex([]byte(creq))}, "\n")
+
+	key := []byte("AWS4" + secret)
+	for _, v := range []string{ts[:8], region, service, "aws4_request"} {
+		key = hmacSHA256(key, v)
+	}
+
+	r.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		id, scope, signed, hex.EncodeToString(hmacSHA256(key, sts))))
+}
+
+// awsEscape escapes all characters except for unreserved characters and optionally slashes
+func awsEscape(s string, escapeSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' ||
+			c == '-' || c == '_' || c == '.' || c == '~' || (c == '/' && !escapeSlash) {
+			b.WriteByte(c)
+			continue
+		}
+
+		fmt.Fprintf(&b, "%%%02X", c)
+	}
+
+	return b.String()
+}
+
+func hashHex(b []byte) string {
+	h := sha256.Sum256(b)
+	return hex.EncodeToString(h[:])
+}
+
+func hmacSHA256(key []byte, v string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(v))
+	return h.Sum(nil)
+}
@@ -0,0 +1,195 @@
+package chop_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stevecallear/chop/v2"
+)
+
+func TestNewConnectionClient(t *testing.T) {
+	type call struct {
+		method  string
+		path    string
+		body    string
+		token   string
+		signed  bool
+		hasDate bool
+	}
+
+	tests := []struct {
+		name   string
+		env    map[string]string
+		status int
+		fn     func(context.Context, chop.ConnectionClient) error
+		exp    call
+		err    error
+	}{
+		{
+			name:   "should post to connections",
+			env:    map[string]string{"AWS_ACCESS_KEY_ID": "id", "AWS_SECRET_ACCESS_KEY": "secret", "AWS_SESSION_TOKEN": "token"},
+			status: http.StatusOK,
+			fn: func(ctx context.Context, c chop.ConnectionClient) error {
+				return c.PostToConnection(ctx, "L0SM9cOFvHcCIhw=", []byte("data"))
+			},
+			exp: call{
+				method:  http.MethodPost,
+				path:    "/dev/@connections/L0SM9cOFvHcCIhw%3D",
+				body:    "data",
+				token:   "token",
+				signed:  true,
+				hasDate: true,
+			},
+		},
+		{
+			name:   "should delete connections",
+			env:    map[string]string{"AWS_ACCESS_KEY_ID": "id", "AWS_SECRET_ACCESS_KEY": "secret"},
+			status: http.StatusNoContent,
+			fn: func(ctx context.Context, c chop.ConnectionClient) error {
+				return c.DeleteConnection(ctx, "id")
+			},
+			exp: call{
+				method:  http.MethodDelete,
+				path:    "/dev/@connections/id",
+				signed:  true,
+				hasDate: true,
+			},
+		},
+		{
+			name:   "should not sign requests without credentials",
+			status: http.StatusOK,
+			fn: func(ctx context.Context, c chop.ConnectionClient) error {
+				return c.PostToConnection(ctx, "id", []byte("data"))
+			},
+			exp: call{
+				method: http.MethodPost,
+				path:   "/dev/@connections/id",
+				body:   "data",
+			},
+		},
+		{
+			name:   "should return gone errors",
+			status: http.StatusGone,
+			fn: func(ctx context.Context, c chop.ConnectionClient) error {
+				return c.PostToConnection(ctx, "id", []byte("data"))
+			},
+			exp: call{
+				method: http.MethodPost,
+				path:   "/dev/@connections/id",
+				body:   "data",
+			},
+			err: chop.ErrConnectionGone,
+		},
+	}
+
+	sigRE := regexp.MustCompile(`^AWS4-HMAC-SHA256 Credential=id/\d{8}/eu-west-1/execute-api/aws4_request, SignedHeaders=host;x-amz-date(;x-amz-security-token)?, Signature=[0-9a-f]{64}$`)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("AWS_REGION", "eu-west-1")
+			for _, k := range []string{"AWS_ACCESS_KEY_ID", "AWS_SECRET_ACCESS_KEY", "AWS_SESSION_TOKEN"} {
+				t.Setenv(k, tt.env[k])
+			}
+
+			var act call
+			s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				b, _ := io.ReadAll(r.Body)
+				act = call{
+					method:  r.Method,
+					path:    r.URL.EscapedPath(),
+					body:    string(b),
+					token:   r.Header.Get("X-Amz-Security-Token"),
+					signed:  sigRE.MatchString(r.Header.Get("Authorization")),
+					hasDate: r.Header.Get("X-Amz-Date") != "",
+				}
+
+				w.WriteHeader(tt.status)
+			}))
+			defer s.Close()
+
+			err := tt.fn(context.Background(), chop.NewConnectionClient(s.URL+"/dev"))
+			assertDeepEqual(t, err, tt.err)
+			assertDeepEqual(t, act, tt.exp)
+		})
+	}
+}
+
+func TestSignRequest(t *testing.T) {
+	const (
+		id     = "AKIDEXAMPLE"
+		secret = "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY"
+	)
+
+	tm := time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		method  string
+		url     string
+		body    string
+		region  string
+		service string
+		exp     string
+	}{
+		{
+			name:    "should match the get-vanilla test vector",
+			method:  http.MethodGet,
+			url:     "https://example.amazonaws.com/",
+			region:  "us-east-1",
+			service: "service",
+			exp:     "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, SignedHeaders=host;x-amz-date, Signature=5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31",
+		},
+		{
+			name:    "should double encode escaped connection ids",
+			method:  http.MethodPost,
+			url:     "https://abcdef1234.execute-api.eu-west-1.amazonaws.com/dev/@connections/" + chop.AWSEscape("L0SM9cOFvHcCIhw=", true),
+			body:    "data",
+			region:  "eu-west-1",
+			service: "execute-api",
+			exp:     "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/eu-west-1/execute-api/aws4_request, SignedHeaders=host;x-amz-date, Signature=758ad4b1084340aff91e96bef9baa349d4935233470ccc9e026bc5431c6169fe",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("AWS_ACCESS_KEY_ID", id)
+			t.Setenv("AWS_SECRET_ACCESS_KEY", secret)
+			t.Setenv("AWS_SESSION_TOKEN", "")
+
+			r, _ := http.NewRequest(tt.method, tt.url, strings.NewReader(tt.body))
+			chop.SignRequest(r, []byte(tt.body), tt.region, tt.service, tm)
+
+			assertDeepEqual(t, r.Header.Get("X-Amz-Date"), "20150830T123600Z")
+			assertDeepEqual(t, r.Header.Get("Authorization"), tt.exp)
+		})
+	}
+}
+
+func TestMemoryConnectionClient(t *testing.T) {
+	ctx := context.Background()
+	c := chop.NewMemoryConnectionClient()
+
+	err := c.PostToConnection(ctx, "id", []byte("a"))
+	assertErrorExists(t, err, false)
+
+	err = c.PostToConnection(ctx, "id", []byte("b"))
+	assertErrorExists(t, err, false)
+
+	assertDeepEqual(t, c.Messages("id"), [][]byte{[]byte("a"), []byte("b")})
+	assertDeepEqual(t, c.Messages("other"), [][]byte(nil))
+
+	err = c.DeleteConnection(ctx, "id")
+	assertErrorExists(t, err, false)
+
+	err = c.PostToConnection(ctx, "id", []byte("c"))
+	assertDeepEqual(t, err, chop.ErrConnectionGone)
+
+	err = c.DeleteConnection(ctx, "id")
+	assertDeepEqual(t, err, chop.ErrConnectionGone)
+}
@@ -0,0 +1,70 @@
+package chop
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/aws/aws-lambda-go/lambda"
+
+	"github.com/stevecallear/chop/v2/internal/event"
+)
+
+// Emulator represents an HTTP handler that converts requests into lambda events of the specified kind,
+// invokes the lambda handler and converts the lambda response into an HTTP response
+type Emulator struct {
+	handler lambda.Handler
+	kind    EventKind
+}
+
+// NewEmulator returns a new emulator for the specified lambda handler and event kind
+// All HTTP event kinds are supported. Only the response fields honoured by the emulated integration are written.
+func NewEmulator(h lambda.Handler, kind EventKind) *Emulator {
+	return &Emulator{
+		handler: h,
+		kind:    kind,
+	}
+}
+
+// ServeHTTP converts the request into a lambda event and writes the lambda response
+// A bad gateway response is written if the lambda handler returns an error or an invalid response
+func (e *Emulator) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	b, err := event.ReadBody(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	ev, err := event.New(string(e.kind), r, b)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	payload, err := json.Marshal(ev)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	rb, err := e.handler.Invoke(r.Context(), payload)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	res, err := event.ReadResponse(string(e.kind), rb)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	defer res.Body.Close()
+
+	h := w.Header()
+	for k, vs := range res.Header {
+		h[k] = vs
+	}
+
+	w.WriteHeader(res.StatusCode)
+	io.Copy(w, res.Body)
+}
@@ -0,0 +1,171 @@
+package chop_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/lambda"
+
+	"github.com/stevecallear/chop/v2"
+)
+
+type invokerFunc func(context.Context, []byte) ([]byte, error)
+
+func (fn invokerFunc) Invoke(ctx context.Context, payload []byte) ([]byte, error) {
+	return fn(ctx, payload)
+}
+
+func TestEmulator(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		info, _ := chop.GetRequestInfo(r)
+
+		http.SetCookie(w, &http.Cookie{Name: "a", Value: "b"})
+		w.Header().Add("X-Value", "1")
+		w.Header().Add("X-Value", "2")
+		w.Header().Set("Content-Type", "text/plain")
+		fmt.Fprintf(w, "%s|%s %s|%s|%s|%s|%s", info.Kind, r.Method, r.URL.String(), b, r.Header.Get("Cookie"), strings.Join(r.Header.Values("X-Test"), ","), r.Host)
+	})
+
+	tests := []struct {
+		name    string
+		kind    chop.EventKind
+		path    string
+		handler lambda.Handler
+		status  int
+		body    string
+		header  http.Header
+	}{
+		{
+			name:   "should emulate api gateway proxy events",
+			kind:   chop.KindAPIGatewayProxy,
+			status: http.StatusOK,
+			body:   "apigateway-proxy|POST /users/1?q=a%2Cb&q=c|body|c=d|a,b|example.com",
+			header: http.Header{"Set-Cookie": {"a=b"}, "X-Value": {"1", "2"}},
+		},
+		{
+			name:   "should emulate api gateway http v2 events",
+			kind:   chop.KindAPIGatewayV2HTTP,
+			status: http.StatusOK,
+			body:   "apigateway-v2-http|POST /users/1?q=a%2Cb&q=c|body|c=d|a,b|example.com",
+			header: http.Header{"Set-Cookie": {"a=b"}, "X-Value": {"1,2"}},
+		},
+		{
+			name:   "should emulate lambda function url events",
+			kind:   chop.KindLambdaFunctionURL,
+			status: http.StatusOK,
+			body:   "lambda-function-url|POST /users/1?q=a%2Cb&q=c|body|c=d|a,b|example.com",
+			header: http.Header{"Set-Cookie": {"a=b"}, "X-Value": {"1,2"}},
+		},
+		{
+			name:   "should emulate alb target group events",
+			kind:   chop.KindALBTargetGroup,
+			status: http.StatusOK,
+			body:   "alb-target-group|POST /users/1?q=c|body|c=d|b|example.com",
+			header: http.Header{"Set-Cookie": {"a=b"}, "X-Value": {"1"}},
+		},
+		{
+			name:   "should emulate api gateway websocket events",
+			kind:   chop.KindAPIGatewayWebsocket,
+			status: http.StatusOK,
+			body:   "apigateway-websocket|POST /users/1?q=a%2Cb&q=c|body||a,b|example.com",
+			header: http.Header{"Set-Cookie": {"a=b"}, "X-Value": {"1", "2"}},
+		},
+		{
+			name:   "should emulate vpc lattice events",
+			kind:   chop.KindVPCLattice,
+			status: http.StatusOK,
+			body:   "vpc-lattice|POST /users/1?q=c|body|c=d|a,b|example.com",
+			header: http.Header{"Set-Cookie": {"a=b"}, "X-Value": {"1,2"}},
+		},
+		{
+			name:   "should emulate vpc lattice v2 events",
+			kind:   chop.KindVPCLatticeV2,
+			status: http.StatusOK,
+			body:   "vpc-lattice-v2|POST /users/1?q=a%2Cb&q=c|body|c=d|a,b|example.com",
+			header: http.Header{"Set-Cookie": {"a=b"}, "X-Value": {"1,2"}},
+		},
+		{
+			name:   "should emulate cloudfront events",
+			kind:   chop.KindCloudFront,
+			status: http.StatusOK,
+			body:   "cloudfront|POST /users/1?q=a%2Cb&q=c|body|c=d|a,b|example.com",
+			header: http.Header{"Set-Cookie": {"a=b"}, "X-Value": {"1", "2"}},
+		},
+		{
+			name:   "should preserve escaped api gateway proxy paths",
+			kind:   chop.KindAPIGatewayProxy,
+			path:   "/100%25",
+			status: http.StatusOK,
+			body:   "apigateway-proxy|POST /100%25|body|c=d|a,b|example.com",
+		},
+		{
+			name:   "should preserve escaped alb target group paths",
+			kind:   chop.KindALBTargetGroup,
+			path:   "/a/c%3Fd",
+			status: http.StatusOK,
+			body:   "alb-target-group|POST /a/c%3Fd|body|c=d|b|example.com",
+		},
+		{
+			name:   "should return bad gateway responses for invocation errors",
+			kind:   chop.KindAPIGatewayProxy,
+			status: http.StatusBadGateway,
+			handler: invokerFunc(func(context.Context, []byte) ([]byte, error) {
+				return nil, errors.New("error")
+			}),
+			body:   "error\n",
+			header: http.Header{},
+		},
+		{
+			name:   "should return internal server error responses for unsupported event kinds",
+			kind:   chop.KindAPIGatewayAuthorizer,
+			status: http.StatusInternalServerError,
+			body:   "unsupported event kind: apigateway-authorizer\n",
+			header: http.Header{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var h lambda.Handler = chop.Wrap(handler)
+			if tt.handler != nil {
+				h = tt.handler
+			}
+
+			s := httptest.NewServer(chop.NewEmulator(h, tt.kind))
+			defer s.Close()
+
+			path := "/users/1?q=a%2Cb&q=c"
+			if tt.path != "" {
+				path = tt.path
+			}
+
+			req, _ := http.NewRequest(http.MethodPost, s.URL+path, strings.NewReader("body"))
+			req.Host = "example.com"
+			req.Header.Set("Cookie", "c=d")
+			req.Header.Add("X-Test", "a")
+			req.Header.Add("X-Test", "b")
+
+			res, err := http.DefaultClient.Do(req)
+			assertErrorExists(t, err, false)
+			if err != nil {
+				return
+			}
+			defer res.Body.Close()
+
+			b, _ := io.ReadAll(res.Body)
+			assertDeepEqual(t, res.StatusCode, tt.status)
+			assertDeepEqual(t, string(b), tt.body)
+
+			for k, v := range tt.header {
+				assertDeepEqual(t, res.Header.Values(k), v)
+			}
+		})
+	}
+}
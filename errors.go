@@ -0,0 +1,42 @@
+package chop
+
+import "net/http"
+
+// ErrorHandler handles errors that occur before the wrapped handler is invoked, such as unsupported or invalid events
+// The processor is nil if the event type is not supported. If the returned error is nil, the response written to
+// the response writer is marshalled by the processor, otherwise the returned error fails the lambda invocation.
+type ErrorHandler func(w http.ResponseWriter, err error, p EventProcessor) error
+
+// DefaultErrorHandler returns the error, failing the lambda invocation
+func DefaultErrorHandler(w http.ResponseWriter, err error, p EventProcessor) error {
+	return err
+}
+
+// BadRequestErrorHandler writes a plain text bad request response if the event type is supported
+// The error is returned if the event type is not supported
+func BadRequestErrorHandler(w http.ResponseWriter, err error, p EventProcessor) error {
+	if p == nil {
+		return err
+	}
+
+	http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+	return nil
+}
+
+// handleError returns nil if the error handler wrote a response for the specified error
+func (h *Handler) handleError(w *ResponseWriter, err error, p EventProcessor) error {
+	eh := h.errorHandler
+	if eh == nil {
+		eh = DefaultErrorHandler
+	}
+
+	if herr := eh(w, err, p); herr != nil {
+		return herr
+	}
+
+	if p == nil {
+		return err // a response cannot be marshalled for unsupported events
+	}
+
+	return nil
+}
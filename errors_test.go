@@ -0,0 +1,131 @@
+package chop_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/stevecallear/chop/v2"
+)
+
+func TestWithErrorHandler(t *testing.T) {
+	errCustom := errors.New("custom")
+
+	tests := []struct {
+		name         string
+		errorHandler chop.ErrorHandler
+		payload      string
+		err          bool
+		expErr       error
+		exp          string
+	}{
+		{
+			name:         "should return unsupported event errors",
+			errorHandler: chop.BadRequestErrorHandler,
+			payload:      `{}`,
+			err:          true,
+			expErr:       chop.ErrUnsupportedEventType,
+		},
+		{
+			name: "should return the unsupported event error if no response can be marshalled",
+			errorHandler: func(w http.ResponseWriter, err error, p chop.EventProcessor) error {
+				return nil
+			},
+			payload: `{}`,
+			err:     true,
+			expErr:  chop.ErrUnsupportedEventType,
+		},
+		{
+			name:         "should return invalid event errors by default",
+			errorHandler: chop.DefaultErrorHandler,
+			payload:      `{"httpMethod":"GET","path":"/resource###%","requestContext":{"apiId":"id"}}`,
+			err:          true,
+		},
+		{
+			name: "should return the error handler error",
+			errorHandler: func(w http.ResponseWriter, err error, p chop.EventProcessor) error {
+				return errCustom
+			},
+			payload: `{"httpMethod":"GET","path":"/resource###%","requestContext":{"apiId":"id"}}`,
+			err:     true,
+			expErr:  errCustom,
+		},
+		{
+			name:         "should write api gateway proxy responses",
+			errorHandler: chop.BadRequestErrorHandler,
+			payload:      `{"httpMethod":"GET","path":"/resource###%","requestContext":{"apiId":"id"}}`,
+			exp:          `{"statusCode":400,"headers":{"Content-Type":"text/plain; charset=utf-8","X-Content-Type-Options":"nosniff"},"multiValueHeaders":{"Content-Type":["text/plain; charset=utf-8"],"X-Content-Type-Options":["nosniff"]},"body":"Bad Request\n"}`,
+		},
+		{
+			name:         "should write api gateway http v2 responses",
+			errorHandler: chop.BadRequestErrorHandler,
+			payload:      `{"version":"2.0","rawPath":"/resource###%","requestContext":{"apiId":"id"}}`,
+			exp:          `{"statusCode":400,"headers":{"Content-Type":"text/plain; charset=utf-8","X-Content-Type-Options":"nosniff"},"multiValueHeaders":{"Content-Type":["text/plain; charset=utf-8"],"X-Content-Type-Options":["nosniff"]},"body":"Bad Request\n","cookies":[]}`,
+		},
+		{
+			name:         "should write lambda function url responses",
+			errorHandler: chop.BadRequestErrorHandler,
+			payload:      `{"version":"2.0","rawPath":"/resource###%","requestContext":{"domainName":"id.lambda-url.eu-west-1.on.aws"}}`,
+			exp:          `{"statusCode":400,"headers":{"Content-Type":"text/plain; charset=utf-8","X-Content-Type-Options":"nosniff"},"body":"Bad Request\n","isBase64Encoded":false,"cookies":[]}`,
+		},
+		{
+			name:         "should write alb target group responses",
+			errorHandler: chop.BadRequestErrorHandler,
+			payload:      `{"httpMethod":"GET","path":"/resource###%","requestContext":{"elb":{}}}`,
+			exp:          `{"statusCode":400,"statusDescription":"400 Bad Request","headers":{"Content-Type":"text/plain; charset=utf-8","X-Content-Type-Options":"nosniff"},"multiValueHeaders":{"Content-Type":["text/plain; charset=utf-8"],"X-Content-Type-Options":["nosniff"]},"body":"Bad Request\n","isBase64Encoded":false}`,
+		},
+		{
+			name:         "should write responses for unmarshal errors",
+			errorHandler: chop.BadRequestErrorHandler,
+			payload:      `{"requestContext":{"apiId":"id"},"resource":"a}`,
+			exp:          `{"statusCode":400,"headers":{"Content-Type":"text/plain; charset=utf-8","X-Content-Type-Options":"nosniff"},"multiValueHeaders":{"Content-Type":["text/plain; charset=utf-8"],"X-Content-Type-Options":["nosniff"]},"body":"Bad Request\n"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := chop.Wrap(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+				t.Error("got handler invocation, expected none")
+			}), chop.WithErrorHandler(tt.errorHandler))
+
+			b, err := h.Invoke(context.Background(), []byte(tt.payload))
+			assertErrorExists(t, err, tt.err)
+			if err != nil {
+				if tt.expErr != nil && !errors.Is(err, tt.expErr) {
+					t.Errorf("got %v, expected %v", err, tt.expErr)
+				}
+
+				return
+			}
+
+			assertDeepEqual(t, string(b), tt.exp)
+		})
+	}
+}
+
+func TestWithErrorHandler_Streaming(t *testing.T) {
+	var act chop.EventProcessor
+	h := chop.Wrap(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		t.Error("got handler invocation, expected none")
+	}), chop.WithErrorHandler(func(w http.ResponseWriter, err error, p chop.EventProcessor) error {
+		act = p
+		return chop.BadRequestErrorHandler(w, err, p)
+	}))
+
+	r, err := h.InvokeStream(context.Background(), []byte(`{"version":"2.0","rawPath":"/resource###%","requestContext":{"domainName":"id.lambda-url.eu-west-1.on.aws"}}`))
+	assertErrorExists(t, err, false)
+
+	b, err := io.ReadAll(r)
+	assertErrorExists(t, err, false)
+
+	exp := `{"statusCode":400,"headers":{"Content-Type":"text/plain; charset=utf-8","X-Content-Type-Options":"nosniff"},"cookies":[]}` +
+		"\x00\x00\x00\x00\x00\x00\x00\x00Bad Request\n"
+
+	assertDeepEqual(t, string(b), exp)
+
+	if _, ok := act.(chop.StreamingEventProcessor); !ok {
+		t.Errorf("got %T, expected streaming event processor", act)
+	}
+}
@@ -0,0 +1,31 @@
+package chop
+
+import (
+	"net"
+	"testing"
+)
+
+// IsolateDefaultRegistry replaces the default registry with a copy that is discarded when the test completes
+func IsolateDefaultRegistry(t testing.TB) {
+	prev := defaultRegistry
+	defaultRegistry = newRegistry(prev.get()...)
+
+	t.Cleanup(func() {
+		defaultRegistry = prev
+	})
+}
+
+var (
+	SignRequest = signRequest
+	AWSEscape   = awsEscape
+)
+
+// IsLocal returns the local address if the handler should be served locally
+func (h *Handler) IsLocal() (string, bool) {
+	return h.isLocal()
+}
+
+// ServeLocal serves the handler over HTTP using the specified listener
+func (h *Handler) ServeLocal(l net.Listener) error {
+	return h.serveLocal(l)
+}
@@ -1,8 +1,13 @@
 module github.com/stevecallear/chop/v2
 
-go 1.16
+go 1.18
 
 require (
-	github.com/aws/aws-lambda-go v1.25.0
+	github.com/aws/aws-lambda-go v1.41.0
 	github.com/tidwall/gjson v1.9.3
 )
+
+require (
+	github.com/tidwall/match v1.1.1 // indirect
+	github.com/tidwall/pretty v1.2.0 // indirect
+)
@@ -0,0 +1,71 @@
+package chop
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/tidwall/gjson"
+)
+
+type (
+	// EventKind represents the kind of lambda event that a request was translated from
+	EventKind string
+
+	// RequestInfo represents normalized request metadata that is common across event types
+	// Fields that are not supported by the event type are empty
+	RequestInfo struct {
+		Kind       EventKind
+		RequestID  string
+		APIID      string
+		Stage      string
+		RouteKey   string
+		SourceIP   string
+		UserAgent  string
+		DomainName string
+		Time       time.Time
+		Authorizer map[string]interface{}
+	}
+
+	requestInfoContextKey struct{}
+)
+
+// Built-in event kinds
+const (
+	KindAPIGatewayProxy     EventKind = "apigateway-proxy"
+	KindAPIGatewayV2HTTP    EventKind = "apigateway-v2-http"
+	KindLambdaFunctionURL   EventKind = "lambda-function-url"
+	KindALBTargetGroup      EventKind = "alb-target-group"
+	KindAPIGatewayWebsocket EventKind = "apigateway-websocket"
+	KindVPCLattice          EventKind = "vpc-lattice"
+	KindVPCLatticeV2        EventKind = "vpc-lattice-v2"
+	KindCloudFront          EventKind = "cloudfront"
+
+	KindAPIGatewayAuthorizer EventKind = "apigateway-authorizer"
+)
+
+// WithRequestInfo returns a copy of the request with the specified request info stored in the request context
+func WithRequestInfo(r *http.Request, info RequestInfo) *http.Request {
+	ctx := context.WithValue(r.Context(), requestInfoContextKey{}, info)
+
+	return r.WithContext(ctx)
+}
+
+// GetRequestInfo returns the request info stored within the specified request context if it exists
+func GetRequestInfo(r *http.Request) (RequestInfo, bool) {
+	info, ok := r.Context().Value(requestInfoContextKey{}).(RequestInfo)
+	return info, ok
+}
+
+func epochMillis(ms int64) time.Time {
+	if ms == 0 {
+		return time.Time{}
+	}
+
+	return time.UnixMilli(ms).UTC()
+}
+
+func authorizerContext(payload []byte) map[string]interface{} {
+	m, _ := gjson.GetBytes(payload, "requestContext.authorizer").Value().(map[string]interface{})
+	return m
+}
@@ -0,0 +1,99 @@
+package chop_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stevecallear/chop/v2"
+)
+
+func TestGetRequestInfo(t *testing.T) {
+	tm := time.Date(2020, 3, 4, 19, 3, 58, 390000000, time.UTC)
+
+	tests := []struct {
+		name    string
+		payload string
+		exp     chop.RequestInfo
+	}{
+		{
+			name:    "should return api gateway proxy request info",
+			payload: `{"httpMethod":"GET","path":"/users/1","resource":"/users/{id}","requestContext":{"apiId":"apiid","requestId":"requestid","stage":"dev","domainName":"api.example.com","requestTimeEpoch":1583348638390,"identity":{"sourceIp":"192.0.2.1","userAgent":"agent"},"authorizer":{"principalId":"user","claims":{"sub":"user"}}}}`,
+			exp: chop.RequestInfo{
+				Kind:       chop.KindAPIGatewayProxy,
+				RequestID:  "requestid",
+				APIID:      "apiid",
+				Stage:      "dev",
+				RouteKey:   "/users/{id}",
+				SourceIP:   "192.0.2.1",
+				UserAgent:  "agent",
+				DomainName: "api.example.com",
+				Time:       tm,
+				Authorizer: map[string]interface{}{
+					"principalId": "user",
+					"claims":      map[string]interface{}{"sub": "user"},
+				},
+			},
+		},
+		{
+			name:    "should return api gateway http v2 request info",
+			payload: `{"version":"2.0","rawPath":"/users/1","routeKey":"GET /users/{id}","requestContext":{"apiId":"apiid","requestId":"requestid","stage":"$default","domainName":"api.example.com","timeEpoch":1583348638390,"http":{"method":"GET","sourceIp":"192.0.2.1","userAgent":"agent"},"authorizer":{"jwt":{"claims":{"sub":"user"},"scopes":null}}}}`,
+			exp: chop.RequestInfo{
+				Kind:       chop.KindAPIGatewayV2HTTP,
+				RequestID:  "requestid",
+				APIID:      "apiid",
+				Stage:      "$default",
+				RouteKey:   "GET /users/{id}",
+				SourceIP:   "192.0.2.1",
+				UserAgent:  "agent",
+				DomainName: "api.example.com",
+				Time:       tm,
+				Authorizer: map[string]interface{}{
+					"jwt": map[string]interface{}{
+						"claims": map[string]interface{}{"sub": "user"},
+						"scopes": nil,
+					},
+				},
+			},
+		},
+		{
+			name:    "should return lambda function url request info",
+			payload: lambdaFunctionURLEventPayload,
+			exp: chop.RequestInfo{
+				Kind:       chop.KindLambdaFunctionURL,
+				RequestID:  "id",
+				APIID:      "urlid",
+				SourceIP:   "127.0.0.1",
+				UserAgent:  "agent",
+				DomainName: "urlid.lambda-url.eu-west-1.on.aws",
+				Time:       tm,
+			},
+		},
+		{
+			name:    "should return alb target group request info",
+			payload: `{"httpMethod":"GET","path":"/users/1","headers":{"host":"lb.example.com","user-agent":"agent","x-forwarded-for":"192.0.2.1"},"requestContext":{"elb":{}}}`,
+			exp: chop.RequestInfo{
+				Kind:       chop.KindALBTargetGroup,
+				SourceIP:   "192.0.2.1",
+				UserAgent:  "agent",
+				DomainName: "lb.example.com",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var act chop.RequestInfo
+			var ok bool
+			h := chop.Wrap(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				act, ok = chop.GetRequestInfo(r)
+			}))
+
+			_, err := h.Invoke(context.Background(), []byte(tt.payload))
+			assertErrorExists(t, err, false)
+			assertDeepEqual(t, ok, true)
+			assertDeepEqual(t, act, tt.exp)
+		})
+	}
+}
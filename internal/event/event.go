@@ -0,0 +1,468 @@
+// Package event creates lambda event payloads from HTTP requests and converts lambda responses into HTTP responses
+// It is shared by the chop emulator and the choptest package so that both produce identical events.
+package event
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// Body represents a lambda event body
+type Body struct {
+	Data            string
+	IsBase64Encoded bool
+}
+
+// Event kinds, matching the chop event kind values
+const (
+	KindAPIGatewayProxy     = "apigateway-proxy"
+	KindAPIGatewayV2HTTP    = "apigateway-v2-http"
+	KindLambdaFunctionURL   = "lambda-function-url"
+	KindALBTargetGroup      = "alb-target-group"
+	KindAPIGatewayWebsocket = "apigateway-websocket"
+	KindVPCLattice          = "vpc-lattice"
+	KindVPCLatticeV2        = "vpc-lattice-v2"
+	KindCloudFront          = "cloudfront"
+)
+
+const (
+	accountID         = "123456789012"
+	apiID             = "abcdef1234"
+	region            = "eu-west-1"
+	stage             = "test"
+	connectionID      = "L0SM9cOFvHcCIhw="
+	distributionID    = "EDFDVBD6EXAMPLE"
+	proxyResource     = "/{proxy+}"
+	defaultRouteKey   = "$default"
+	defaultStage      = "$default"
+	defaultSourceIP   = "192.0.2.1"
+	requestTimeFormat = "02/Jan/2006:15:04:05 -0700"
+)
+
+var (
+	targetGroupARN    = "arn:aws:elasticloadbalancing:" + region + ":" + accountID + ":targetgroup/test/0123456789abcdef"
+	serviceNetworkARN = "arn:aws:vpc-lattice:" + region + ":" + accountID + ":servicenetwork/sn-0123456789abcdef0"
+	serviceARN        = "arn:aws:vpc-lattice:" + region + ":" + accountID + ":service/svc-0123456789abcdef0"
+	sourceVPCARN      = "arn:aws:ec2:" + region + ":" + accountID + ":vpc/vpc-0123456789abcdef0"
+)
+
+// New returns the lambda event of the specified kind for the request and body
+func New(kind string, r *http.Request, b Body) (interface{}, error) {
+	switch kind {
+	case KindAPIGatewayProxy:
+		return NewAPIGatewayProxy(r, b), nil
+	case KindAPIGatewayWebsocket:
+		return NewAPIGatewayWebsocket(r, b), nil
+	case KindAPIGatewayV2HTTP:
+		return NewAPIGatewayV2HTTP(r, b), nil
+	case KindLambdaFunctionURL:
+		return NewLambdaFunctionURL(r, b), nil
+	case KindALBTargetGroup:
+		return NewALBTargetGroup(r, b), nil
+	case KindVPCLattice:
+		return NewVPCLattice(r, b), nil
+	case KindVPCLatticeV2:
+		return NewVPCLatticeV2(r, b), nil
+	case KindCloudFront:
+		return NewCloudFront(r, b), nil
+	}
+
+	return nil, fmt.Errorf("unsupported event kind: %s", kind)
+}
+
+// NewAPIGatewayProxy returns an API gateway proxy event for the request
+func NewAPIGatewayProxy(r *http.Request, b Body) *events.APIGatewayProxyRequest {
+	t := time.Now().UTC()
+	id := newRequestID()
+	h := eventHeader(r, true)
+	path := r.URL.EscapedPath()
+
+	return &events.APIGatewayProxyRequest{
+		Resource:                        proxyResource,
+		Path:                            path,
+		HTTPMethod:                      r.Method,
+		Headers:                         lastValues(h),
+		MultiValueHeaders:               h,
+		QueryStringParameters:           lastValues(r.URL.Query()),
+		MultiValueQueryStringParameters: nilIfEmpty(r.URL.Query()),
+		PathParameters:                  map[string]string{"proxy": strings.TrimPrefix(r.URL.Path, "/")},
+		RequestContext: events.APIGatewayProxyRequestContext{
+			AccountID:        accountID,
+			ResourceID:       "abc123",
+			Stage:            stage,
+			RequestID:        id,
+			Identity:         events.APIGatewayRequestIdentity{SourceIP: sourceIP(r), UserAgent: r.UserAgent()},
+			ResourcePath:     proxyResource,
+			Path:             "/" + stage + path,
+			HTTPMethod:       r.Method,
+			RequestTime:      t.Format(requestTimeFormat),
+			RequestTimeEpoch: t.UnixMilli(),
+			APIID:            apiID,
+			DomainName:       r.Host,
+			DomainPrefix:     domainPrefix(r.Host),
+			Protocol:         protocol(r),
+		},
+		Body:            b.Data,
+		IsBase64Encoded: b.IsBase64Encoded,
+	}
+}
+
+// NewAPIGatewayWebsocket returns an API gateway websocket message event for the request
+// The route key is the request path without the leading slash
+func NewAPIGatewayWebsocket(r *http.Request, b Body) *events.APIGatewayWebsocketProxyRequest {
+	t := time.Now().UTC()
+	id := newRequestID()
+	h := eventHeader(r, false)
+
+	routeKey := strings.TrimPrefix(r.URL.Path, "/")
+	if routeKey == "" {
+		routeKey = defaultRouteKey
+	}
+
+	return &events.APIGatewayWebsocketProxyRequest{
+		Headers:                         lastValues(h),
+		MultiValueHeaders:               h,
+		QueryStringParameters:           lastValues(r.URL.Query()),
+		MultiValueQueryStringParameters: nilIfEmpty(r.URL.Query()),
+		RequestContext: events.APIGatewayWebsocketProxyRequestContext{
+			AccountID:         accountID,
+			Stage:             stage,
+			RequestID:         id,
+			Identity:          events.APIGatewayRequestIdentity{SourceIP: sourceIP(r), UserAgent: r.UserAgent()},
+			APIID:             apiID,
+			ConnectedAt:       t.UnixMilli(),
+			ConnectionID:      connectionID,
+			DomainName:        r.Host,
+			EventType:         "MESSAGE",
+			ExtendedRequestID: id,
+			MessageDirection:  "IN",
+			MessageID:         "f2hSmfRnDoECIzw=",
+			RequestTime:       t.Format(requestTimeFormat),
+			RequestTimeEpoch:  t.UnixMilli(),
+			RouteKey:          routeKey,
+		},
+		Body:            b.Data,
+		IsBase64Encoded: b.IsBase64Encoded,
+	}
+}
+
+// NewAPIGatewayV2HTTP returns an API gateway http v2 event for the request
+func NewAPIGatewayV2HTTP(r *http.Request, b Body) *events.APIGatewayV2HTTPRequest {
+	t := time.Now().UTC()
+
+	return &events.APIGatewayV2HTTPRequest{
+		Version:               "2.0",
+		RouteKey:              defaultRouteKey,
+		RawPath:               r.URL.EscapedPath(),
+		RawQueryString:        r.URL.RawQuery,
+		Cookies:               cookies(r),
+		Headers:               lowerValues(joinedValues(eventHeader(r, false))),
+		QueryStringParameters: joinedValues(r.URL.Query()),
+		RequestContext: events.APIGatewayV2HTTPRequestContext{
+			RouteKey:     defaultRouteKey,
+			AccountID:    accountID,
+			Stage:        defaultStage,
+			RequestID:    newRequestID(),
+			APIID:        apiID,
+			DomainName:   r.Host,
+			DomainPrefix: domainPrefix(r.Host),
+			Time:         t.Format(requestTimeFormat),
+			TimeEpoch:    t.UnixMilli(),
+			HTTP: events.APIGatewayV2HTTPRequestContextHTTPDescription{
+				Method:    r.Method,
+				Path:      r.URL.Path,
+				Protocol:  protocol(r),
+				SourceIP:  sourceIP(r),
+				UserAgent: r.UserAgent(),
+			},
+		},
+		Body:            b.Data,
+		IsBase64Encoded: b.IsBase64Encoded,
+	}
+}
+
+// NewLambdaFunctionURL returns a lambda function url event for the request
+func NewLambdaFunctionURL(r *http.Request, b Body) *events.LambdaFunctionURLRequest {
+	t := time.Now().UTC()
+
+	return &events.LambdaFunctionURLRequest{
+		Version:               "2.0",
+		RawPath:               r.URL.EscapedPath(),
+		RawQueryString:        r.URL.RawQuery,
+		Cookies:               cookies(r),
+		Headers:               lowerValues(joinedValues(eventHeader(r, false))),
+		QueryStringParameters: joinedValues(r.URL.Query()),
+		RequestContext: events.LambdaFunctionURLRequestContext{
+			AccountID:    "anonymous",
+			RequestID:    newRequestID(),
+			APIID:        apiID,
+			DomainName:   apiID + ".lambda-url." + region + ".on.aws",
+			DomainPrefix: apiID,
+			Time:         t.Format(requestTimeFormat),
+			TimeEpoch:    t.UnixMilli(),
+			HTTP: events.LambdaFunctionURLRequestContextHTTPDescription{
+				Method:    r.Method,
+				Path:      r.URL.Path,
+				Protocol:  protocol(r),
+				SourceIP:  sourceIP(r),
+				UserAgent: r.UserAgent(),
+			},
+		},
+		Body:            b.Data,
+		IsBase64Encoded: b.IsBase64Encoded,
+	}
+}
+
+// NewALBTargetGroup returns an alb target group event for the request
+// Multi-value headers are disabled, matching the target group default
+func NewALBTargetGroup(r *http.Request, b Body) *events.ALBTargetGroupRequest {
+	return &events.ALBTargetGroupRequest{
+		HTTPMethod:            r.Method,
+		Path:                  r.URL.EscapedPath(),
+		QueryStringParameters: lastValues(r.URL.Query()),
+		Headers:               lowerValues(lastValues(eventHeader(r, true))),
+		RequestContext: events.ALBTargetGroupRequestContext{
+			ELB: events.ELBContext{TargetGroupArn: targetGroupARN},
+		},
+		Body:            b.Data,
+		IsBase64Encoded: b.IsBase64Encoded,
+	}
+}
+
+// NewVPCLattice returns a VPC lattice version 1.0 event for the request
+func NewVPCLattice(r *http.Request, b Body) *VPCLatticeRequest {
+	return &VPCLatticeRequest{
+		Method:                r.Method,
+		RawPath:               r.URL.EscapedPath(),
+		Headers:               lowerValues(joinedValues(eventHeader(r, true))),
+		QueryStringParameters: lastValues(r.URL.Query()),
+		Body:                  b.Data,
+		IsBase64Encoded:       b.IsBase64Encoded,
+	}
+}
+
+// NewVPCLatticeV2 returns a VPC lattice version 2.0 event for the request
+func NewVPCLatticeV2(r *http.Request, b Body) *VPCLatticeRequestV2 {
+	return &VPCLatticeRequestV2{
+		Version:               "2.0",
+		Path:                  r.URL.EscapedPath(),
+		Method:                r.Method,
+		Headers:               lowerValues(eventHeader(r, true)),
+		QueryStringParameters: nilIfEmpty(r.URL.Query()),
+		Body:                  b.Data,
+		IsBase64Encoded:       b.IsBase64Encoded,
+		RequestContext: VPCLatticeRequestContextV2{
+			ServiceNetworkARN: serviceNetworkARN,
+			ServiceARN:        serviceARN,
+			TargetGroupARN:    targetGroupARN,
+			Identity: VPCLatticeIdentity{
+				SourceVPCARN: sourceVPCARN,
+				Type:         "NONE",
+			},
+			Region:    region,
+			TimeEpoch: strconv.FormatInt(time.Now().UnixMicro(), 10),
+		},
+	}
+}
+
+// NewCloudFront returns a CloudFront Lambda@Edge viewer request event for the request
+func NewCloudFront(r *http.Request, b Body) *CloudFrontEvent {
+	h := r.Header.Clone()
+	if r.Host != "" {
+		h.Set("Host", r.Host)
+	}
+
+	ch := make(CloudFrontHeaders, len(h))
+	for k, vs := range h {
+		lk := strings.ToLower(k)
+		for _, v := range vs {
+			ch[lk] = append(ch[lk], CloudFrontHeader{Key: k, Value: v})
+		}
+	}
+
+	var cb *CloudFrontRequestBody
+	if b.Data != "" {
+		data := b.Data
+		if !b.IsBase64Encoded {
+			data = base64.StdEncoding.EncodeToString([]byte(b.Data))
+		}
+
+		cb = &CloudFrontRequestBody{
+			Action:   "read-only",
+			Encoding: "base64",
+			Data:     data,
+		}
+	}
+
+	return &CloudFrontEvent{
+		Records: []CloudFrontEventRecord{{
+			CF: CloudFrontEventCF{
+				Config: CloudFrontConfig{
+					DistributionDomainName: "d111111abcdef8.cloudfront.net",
+					DistributionID:         distributionID,
+					EventType:              "viewer-request",
+					RequestID:              newRequestID(),
+				},
+				Request: CloudFrontRequest{
+					ClientIP:    sourceIP(r),
+					Headers:     ch,
+					Method:      r.Method,
+					QueryString: r.URL.RawQuery,
+					URI:         r.URL.EscapedPath(),
+					Body:        cb,
+				},
+			},
+		}},
+	}
+}
+
+// ReadBody reads the request body, replacing it so that the request can be reused
+// Bodies that are not valid UTF-8 are base64 encoded
+func ReadBody(r *http.Request) (Body, error) {
+	if r.Body == nil || r.Body == http.NoBody {
+		return Body{}, nil
+	}
+
+	b, err := io.ReadAll(r.Body)
+	if err != nil {
+		return Body{}, err
+	}
+
+	r.Body.Close()
+	r.Body = io.NopCloser(bytes.NewReader(b))
+
+	if !utf8.Valid(b) {
+		return Body{Data: base64.StdEncoding.EncodeToString(b), IsBase64Encoded: true}, nil
+	}
+
+	return Body{Data: string(b)}, nil
+}
+
+// eventHeader returns the request headers including the host and forwarded headers added by AWS
+// Cookies are excluded if the event represents them separately
+func eventHeader(r *http.Request, withCookies bool) http.Header {
+	h := r.Header.Clone()
+	if r.Host != "" {
+		h.Set("Host", r.Host)
+	}
+
+	if !withCookies {
+		h.Del("Cookie")
+	}
+
+	if h.Get("X-Forwarded-For") == "" {
+		h.Set("X-Forwarded-For", sourceIP(r))
+	}
+
+	if h.Get("X-Forwarded-Proto") == "" {
+		proto, port := "http", "80"
+		if r.TLS != nil || r.URL.Scheme == "https" {
+			proto, port = "https", "443"
+		}
+
+		h.Set("X-Forwarded-Proto", proto)
+		h.Set("X-Forwarded-Port", port)
+	}
+
+	return h
+}
+
+func sourceIP(r *http.Request) string {
+	if r.RemoteAddr == "" {
+		return defaultSourceIP
+	}
+
+	if h, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return h
+	}
+
+	return r.RemoteAddr
+}
+
+func protocol(r *http.Request) string {
+	if r.Proto == "" {
+		return "HTTP/1.1"
+	}
+
+	return r.Proto
+}
+
+func domainPrefix(host string) string {
+	return strings.SplitN(host, ".", 2)[0]
+}
+
+func cookies(r *http.Request) []string {
+	var cs []string
+	for _, c := range r.Cookies() {
+		cs = append(cs, c.String())
+	}
+
+	return cs
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+
+	return hex.EncodeToString(b)
+}
+
+// lastValues returns the last value of each key, matching the AWS single value maps
+func lastValues(m map[string][]string) map[string]string {
+	if len(m) == 0 {
+		return nil
+	}
+
+	sv := make(map[string]string, len(m))
+	for k, vs := range m {
+		sv[k] = vs[len(vs)-1]
+	}
+
+	return sv
+}
+
+func joinedValues(m map[string][]string) map[string]string {
+	if len(m) == 0 {
+		return nil
+	}
+
+	jv := make(map[string]string, len(m))
+	for k, vs := range m {
+		jv[k] = strings.Join(vs, ",")
+	}
+
+	return jv
+}
+
+func lowerValues[T any](m map[string]T) map[string]T {
+	if len(m) == 0 {
+		return nil
+	}
+
+	lv := make(map[string]T, len(m))
+	for k, v := range m {
+		lv[strings.ToLower(k)] = v
+	}
+
+	return lv
+}
+
+func nilIfEmpty(m map[string][]string) map[string][]string {
+	if len(m) == 0 {
+		return nil
+	}
+
+	return m
+}
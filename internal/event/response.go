@@ -0,0 +1,144 @@
+package event
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+)
+
+type response struct {
+	StatusCode        int                 `json:"statusCode"`
+	StatusDescription string              `json:"statusDescription"`
+	Headers           map[string]string   `json:"headers"`
+	MultiValueHeaders map[string][]string `json:"multiValueHeaders"`
+	Cookies           []string            `json:"cookies"`
+	Body              string              `json:"body"`
+	IsBase64Encoded   bool                `json:"isBase64Encoded"`
+}
+
+// ErrUnsupportedResponse indicates that the lambda response payload is not a supported HTTP response
+var ErrUnsupportedResponse = errors.New("unsupported lambda response")
+
+// ReadResponse converts the lambda response payload for the specified event kind into an HTTP response
+// Only the response fields that the integration for the event kind honours are used.
+func ReadResponse(kind string, payload []byte) (*http.Response, error) {
+	if kind == KindCloudFront {
+		return readCloudFrontResponse(payload)
+	}
+
+	r := new(response)
+	if err := json.Unmarshal(payload, r); err != nil {
+		return nil, err
+	}
+
+	if r.StatusCode == 0 {
+		return nil, ErrUnsupportedResponse
+	}
+
+	h := make(http.Header)
+	status := ""
+
+	switch kind {
+	case KindAPIGatewayProxy, KindAPIGatewayWebsocket:
+		// multi-value headers replace single value headers with the same name
+		for k, v := range r.Headers {
+			if _, ok := r.MultiValueHeaders[k]; !ok {
+				h.Add(k, v)
+			}
+		}
+		for k, vs := range r.MultiValueHeaders {
+			for _, v := range vs {
+				h.Add(k, v)
+			}
+		}
+
+	case KindAPIGatewayV2HTTP, KindLambdaFunctionURL:
+		for k, v := range r.Headers {
+			h.Add(k, v)
+		}
+		for _, c := range r.Cookies {
+			h.Add("Set-Cookie", c)
+		}
+
+	case KindALBTargetGroup, KindVPCLattice, KindVPCLatticeV2:
+		for k, v := range r.Headers {
+			h.Add(k, v)
+		}
+		status = r.StatusDescription
+
+	default:
+		return nil, fmt.Errorf("unsupported event kind: %s", kind)
+	}
+
+	encoding := ""
+	if r.IsBase64Encoded {
+		encoding = "base64"
+	}
+
+	return newResponse(r.StatusCode, status, h, r.Body, encoding)
+}
+
+func readCloudFrontResponse(payload []byte) (*http.Response, error) {
+	r := new(CloudFrontResponse)
+	if err := json.Unmarshal(payload, r); err != nil {
+		return nil, err
+	}
+
+	if r.Status == "" {
+		return nil, ErrUnsupportedResponse
+	}
+
+	code, err := strconv.Atoi(r.Status)
+	if err != nil {
+		return nil, fmt.Errorf("invalid status: %s", r.Status)
+	}
+
+	h := make(http.Header)
+	for k, vs := range r.Headers {
+		for _, v := range vs {
+			key := v.Key
+			if key == "" {
+				key = k
+			}
+
+			h.Add(key, v.Value)
+		}
+	}
+
+	status := ""
+	if r.StatusDescription != "" {
+		status = r.Status + " " + r.StatusDescription
+	}
+
+	return newResponse(code, status, h, r.Body, r.BodyEncoding)
+}
+
+func newResponse(code int, status string, h http.Header, body, encoding string) (*http.Response, error) {
+	b := []byte(body)
+	if encoding == "base64" {
+		var err error
+		if b, err = base64.StdEncoding.DecodeString(body); err != nil {
+			return nil, err
+		}
+	}
+
+	if status == "" {
+		status = fmt.Sprintf("%d %s", code, http.StatusText(code))
+	}
+
+	return &http.Response{
+		Status:        status,
+		StatusCode:    code,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        h,
+		Body:          io.NopCloser(bytes.NewReader(b)),
+		ContentLength: int64(len(b)),
+	}, nil
+}
@@ -0,0 +1,121 @@
+package event
+
+type (
+	// VPCLatticeRequest represents a VPC lattice version 1.0 lambda target event
+	VPCLatticeRequest struct {
+		Method                string            `json:"method"`
+		RawPath               string            `json:"raw_path"`
+		Headers               map[string]string `json:"headers"`
+		QueryStringParameters map[string]string `json:"query_string_parameters"`
+		Body                  string            `json:"body"`
+		IsBase64Encoded       bool              `json:"is_base64_encoded"`
+	}
+
+	// VPCLatticeRequestV2 represents a VPC lattice version 2.0 lambda target event
+	VPCLatticeRequestV2 struct {
+		Version               string                     `json:"version"`
+		Path                  string                     `json:"path"`
+		Method                string                     `json:"method"`
+		Headers               map[string][]string        `json:"headers"`
+		QueryStringParameters map[string][]string        `json:"queryStringParameters"`
+		Body                  string                     `json:"body"`
+		IsBase64Encoded       bool                       `json:"isBase64Encoded"`
+		RequestContext        VPCLatticeRequestContextV2 `json:"requestContext"`
+	}
+
+	// VPCLatticeRequestContextV2 represents the request context of a VPC lattice version 2.0 event
+	VPCLatticeRequestContextV2 struct {
+		ServiceNetworkARN string             `json:"serviceNetworkArn"`
+		ServiceARN        string             `json:"serviceArn"`
+		TargetGroupARN    string             `json:"targetGroupArn"`
+		Identity          VPCLatticeIdentity `json:"identity"`
+		Region            string             `json:"region"`
+		TimeEpoch         string             `json:"timeEpoch"`
+	}
+
+	// VPCLatticeIdentity represents the identity of the caller of a VPC lattice version 2.0 event
+	VPCLatticeIdentity struct {
+		SourceVPCARN   string `json:"sourceVpcArn"`
+		Type           string `json:"type"`
+		Principal      string `json:"principal"`
+		PrincipalOrgID string `json:"principalOrgID"`
+		SessionName    string `json:"sessionName"`
+		X509SanDNS     string `json:"x509SanDns"`
+		X509SanNameCN  string `json:"x509SanNameCn"`
+		X509SubjectCN  string `json:"x509SubjectCn"`
+		X509IssuerOU   string `json:"x509IssuerOu"`
+		X509SanURI     string `json:"x509SanUri"`
+	}
+
+	// VPCLatticeResponse represents a VPC lattice lambda target response
+	VPCLatticeResponse struct {
+		StatusCode        int               `json:"statusCode"`
+		StatusDescription string            `json:"statusDescription,omitempty"`
+		Headers           map[string]string `json:"headers"`
+		Body              string            `json:"body"`
+		IsBase64Encoded   bool              `json:"isBase64Encoded"`
+	}
+)
+
+type (
+	// CloudFrontEvent represents a CloudFront Lambda@Edge event
+	CloudFrontEvent struct {
+		Records []CloudFrontEventRecord `json:"Records"`
+	}
+
+	// CloudFrontEventRecord represents a CloudFront Lambda@Edge event record
+	CloudFrontEventRecord struct {
+		CF CloudFrontEventCF `json:"cf"`
+	}
+
+	// CloudFrontEventCF represents the CloudFront data of a Lambda@Edge event record
+	CloudFrontEventCF struct {
+		Config  CloudFrontConfig  `json:"config"`
+		Request CloudFrontRequest `json:"request"`
+	}
+
+	// CloudFrontConfig represents the CloudFront distribution configuration of a Lambda@Edge event
+	CloudFrontConfig struct {
+		DistributionDomainName string `json:"distributionDomainName"`
+		DistributionID         string `json:"distributionId"`
+		EventType              string `json:"eventType"`
+		RequestID              string `json:"requestId"`
+	}
+
+	// CloudFrontRequest represents a CloudFront Lambda@Edge request
+	CloudFrontRequest struct {
+		ClientIP    string                 `json:"clientIp"`
+		Headers     CloudFrontHeaders      `json:"headers"`
+		Method      string                 `json:"method"`
+		QueryString string                 `json:"querystring"`
+		URI         string                 `json:"uri"`
+		Body        *CloudFrontRequestBody `json:"body,omitempty"`
+		Origin      map[string]interface{} `json:"origin,omitempty"`
+	}
+
+	// CloudFrontRequestBody represents the body of a CloudFront Lambda@Edge request
+	CloudFrontRequestBody struct {
+		InputTruncated bool   `json:"inputTruncated"`
+		Action         string `json:"action"`
+		Encoding       string `json:"encoding"`
+		Data           string `json:"data"`
+	}
+
+	// CloudFrontResponse represents a CloudFront Lambda@Edge generated response
+	CloudFrontResponse struct {
+		Status            string            `json:"status"`
+		StatusDescription string            `json:"statusDescription,omitempty"`
+		Headers           CloudFrontHeaders `json:"headers"`
+		Body              string            `json:"body,omitempty"`
+		BodyEncoding      string            `json:"bodyEncoding,omitempty"`
+	}
+
+	// CloudFrontHeaders represents CloudFront headers, keyed by lower case header name
+	CloudFrontHeaders map[string][]CloudFrontHeader
+
+	// CloudFrontHeader represents a CloudFront header value
+	CloudFrontHeader struct {
+		Key   string `json:"key,omitempty"`
+		Value string `json:"value"`
+	}
+)
@@ -0,0 +1,35 @@
+package chop
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/stevecallear/chop/v2/internal/event"
+)
+
+type (
+	// VPCLatticeRequest represents a VPC lattice version 1.0 lambda target event
+	VPCLatticeRequest = event.VPCLatticeRequest
+
+	// VPCLatticeRequestV2 represents a VPC lattice version 2.0 lambda target event
+	VPCLatticeRequestV2 = event.VPCLatticeRequestV2
+
+	// VPCLatticeRequestContextV2 represents the request context of a VPC lattice version 2.0 event
+	VPCLatticeRequestContextV2 = event.VPCLatticeRequestContextV2
+
+	// VPCLatticeIdentity represents the identity of the caller of a VPC lattice version 2.0 event
+	VPCLatticeIdentity = event.VPCLatticeIdentity
+
+	// VPCLatticeResponse represents a VPC lattice lambda target response
+	VPCLatticeResponse = event.VPCLatticeResponse
+)
+
+// epochMicros parses the specified microsecond epoch string, returning the zero time if it is invalid
+func epochMicros(s string) time.Time {
+	us, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || us == 0 {
+		return time.Time{}
+	}
+
+	return time.UnixMicro(us).UTC()
+}
@@ -0,0 +1,112 @@
+package chop_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stevecallear/chop/v2"
+)
+
+func TestHandler_Invoke_VPCLattice(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload string
+		handler http.HandlerFunc
+		exp     request
+		info    chop.RequestInfo
+		event   string
+		res     *chop.VPCLatticeResponse
+	}{
+		{
+			name:    "should handle vpc lattice events",
+			payload: `{"raw_path":"/users/1","method":"POST","headers":{"host":"svc.example.com","user-agent":"agent","x-forwarded-for":"10.0.2.100","content-type":"text/plain"},"query_string_parameters":{"a":"b"},"body":"body","is_base64_encoded":false}`,
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Add("Set-Cookie", "a=b")
+				w.Header().Add("Set-Cookie", "c=d")
+				w.WriteHeader(http.StatusCreated)
+				w.Write([]byte("created"))
+			},
+			exp: request{
+				method: http.MethodPost,
+				url:    "/users/1?a=b",
+				body:   "body",
+				header: http.Header{
+					"User-Agent":      {"agent"},
+					"X-Forwarded-For": {"10.0.2.100"},
+					"Content-Type":    {"text/plain"},
+				},
+			},
+			info: chop.RequestInfo{
+				Kind:       chop.KindVPCLattice,
+				SourceIP:   "10.0.2.100",
+				UserAgent:  "agent",
+				DomainName: "svc.example.com",
+			},
+			event: fmt.Sprintf("%T", (*chop.VPCLatticeRequest)(nil)),
+			res: &chop.VPCLatticeResponse{
+				StatusCode:        http.StatusCreated,
+				StatusDescription: toStatusDescription(http.StatusCreated),
+				Headers: map[string]string{
+					"Set-Cookie": "a=b,c=d",
+				},
+				Body: "created",
+			},
+		},
+		{
+			name:    "should handle vpc lattice v2 events",
+			payload: `{"version":"2.0","path":"/users/1","method":"GET","headers":{"host":["svc.example.com"],"x-forwarded-for":["10.0.2.100"]},"queryStringParameters":{"a":["b","c"]},"body":"","isBase64Encoded":false,"requestContext":{"serviceNetworkArn":"arn:aws:vpc-lattice:eu-west-1:123456789012:servicenetwork/sn-1","serviceArn":"arn:aws:vpc-lattice:eu-west-1:123456789012:service/svc-1","targetGroupArn":"arn:aws:vpc-lattice:eu-west-1:123456789012:targetgroup/tg-1","identity":{"type":"AWS_IAM","principal":"arn:aws:iam::123456789012:role/r"},"region":"eu-west-1","timeEpoch":"1583348638390000"}}`,
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte{0xff})
+			},
+			exp: request{
+				method: http.MethodGet,
+				url:    "/users/1?a=b&a=c",
+				header: http.Header{"X-Forwarded-For": {"10.0.2.100"}},
+			},
+			info: chop.RequestInfo{
+				Kind:       chop.KindVPCLatticeV2,
+				SourceIP:   "10.0.2.100",
+				DomainName: "svc.example.com",
+				Time:       time.Date(2020, 3, 4, 19, 3, 58, 390000000, time.UTC),
+			},
+			event: fmt.Sprintf("%T", (*chop.VPCLatticeRequestV2)(nil)),
+			res: &chop.VPCLatticeResponse{
+				StatusCode:        http.StatusOK,
+				StatusDescription: toStatusDescription(http.StatusOK),
+				Headers:           map[string]string{"Content-Type": "text/plain; charset=utf-8"},
+				Body:              "/w==",
+				IsBase64Encoded:   true,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var req request
+			var info chop.RequestInfo
+			var event string
+
+			h := chop.Wrap(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				req = toRequest(r)
+				info, _ = chop.GetRequestInfo(r)
+				event = fmt.Sprintf("%T", chop.GetEvent(r))
+				tt.handler(w, r)
+			}))
+
+			b, err := h.Invoke(context.Background(), []byte(tt.payload))
+			assertErrorExists(t, err, false)
+			assertDeepEqual(t, req, tt.exp)
+			assertDeepEqual(t, info, tt.info)
+			assertDeepEqual(t, event, tt.event)
+
+			res := new(chop.VPCLatticeResponse)
+			err = json.Unmarshal(b, res)
+			assertErrorExists(t, err, false)
+			assertDeepEqual(t, res, tt.res)
+		})
+	}
+}
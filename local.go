@@ -0,0 +1,55 @@
+package chop
+
+import (
+	"log"
+	"net"
+	"net/http"
+	"os"
+)
+
+const (
+	// LocalAddrEnv is the environment variable used to serve the handler locally on the specified address
+	LocalAddrEnv = "CHOP_LOCAL_ADDR"
+
+	defaultLocalAddr = ":8080"
+)
+
+// isLocal returns the local address if the handler should be served locally rather than started
+// as a lambda function handler
+func (h *Handler) isLocal() (string, bool) {
+	if h.localAddr != "" {
+		return h.localAddr, true
+	}
+
+	if addr := os.Getenv(LocalAddrEnv); addr != "" {
+		return addr, true
+	}
+
+	if os.Getenv("AWS_LAMBDA_RUNTIME_API") != "" || os.Getenv("_LAMBDA_SERVER_PORT") != "" {
+		return "", false
+	}
+
+	return defaultLocalAddr, true
+}
+
+// listenLocal listens on the specified address and serves the handler over HTTP
+func (h *Handler) listenLocal(addr string) error {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("chop: serving locally on %s", l.Addr())
+	return h.serveLocal(l)
+}
+
+// serveLocal serves the handler over HTTP using the specified listener
+// Requests are wrapped in synthetic api gateway http v2 events if local events are enabled
+func (h *Handler) serveLocal(l net.Listener) error {
+	var hh http.Handler = h.Handler
+	if h.localEvents {
+		hh = NewEmulator(h, KindAPIGatewayV2HTTP)
+	}
+
+	return http.Serve(l, hh)
+}
@@ -0,0 +1,125 @@
+package chop_test
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stevecallear/chop/v2"
+)
+
+func TestHandler_IsLocal(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    []chop.Option
+		env     map[string]string
+		addr    string
+		isLocal bool
+	}{
+		{
+			name:    "should serve locally on the default address outside of lambda environments",
+			addr:    ":8080",
+			isLocal: true,
+		},
+		{
+			name:    "should use the address option",
+			opts:    []chop.Option{chop.WithLocalAddr(":3000")},
+			env:     map[string]string{chop.LocalAddrEnv: ":4000", "AWS_LAMBDA_RUNTIME_API": "localhost:9001"},
+			addr:    ":3000",
+			isLocal: true,
+		},
+		{
+			name:    "should use the address environment variable",
+			env:     map[string]string{chop.LocalAddrEnv: ":4000", "AWS_LAMBDA_RUNTIME_API": "localhost:9001"},
+			addr:    ":4000",
+			isLocal: true,
+		},
+		{
+			name: "should not serve locally in runtime api environments",
+			env:  map[string]string{"AWS_LAMBDA_RUNTIME_API": "localhost:9001"},
+		},
+		{
+			name: "should not serve locally in rpc environments",
+			env:  map[string]string{"_LAMBDA_SERVER_PORT": "8001"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, k := range []string{chop.LocalAddrEnv, "AWS_LAMBDA_RUNTIME_API", "_LAMBDA_SERVER_PORT"} {
+				t.Setenv(k, tt.env[k])
+			}
+
+			addr, ok := chop.Wrap(http.NotFoundHandler(), tt.opts...).IsLocal()
+			assertDeepEqual(t, addr, tt.addr)
+			assertDeepEqual(t, ok, tt.isLocal)
+		})
+	}
+}
+
+func TestHandler_ServeLocal(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "a", Value: "b"})
+		b, _ := io.ReadAll(r.Body)
+		info, _ := chop.GetRequestInfo(r)
+
+		fmt.Fprintf(w, "%T|%s|%s %s|%s|%s", chop.GetEvent(r), info.Kind, r.Method, r.URL.String(), b, r.Header.Get("Cookie"))
+	})
+
+	tests := []struct {
+		name string
+		opts []chop.Option
+		exp  string
+	}{
+		{
+			name: "should serve the handler directly",
+			exp:  "<nil>||POST /users/1?a=b|body|c=d",
+		},
+		{
+			name: "should wrap requests in synthetic events",
+			opts: []chop.Option{chop.WithLocalEvents()},
+			exp:  "*events.APIGatewayV2HTTPRequest|apigateway-v2-http|POST /users/1?a=b|body|c=d",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, err := net.Listen("tcp", "127.0.0.1:0")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer l.Close()
+
+			go chop.Wrap(handler, tt.opts...).ServeLocal(l)
+
+			req, _ := http.NewRequest(http.MethodPost, "http://"+l.Addr().String()+"/users/1?a=b", strings.NewReader("body"))
+			req.Header.Set("Cookie", "c=d")
+
+			res, err := http.DefaultClient.Do(req)
+			assertErrorExists(t, err, false)
+			if err != nil {
+				return
+			}
+			defer res.Body.Close()
+
+			b, _ := io.ReadAll(res.Body)
+			assertDeepEqual(t, res.StatusCode, http.StatusOK)
+			assertDeepEqual(t, res.Header.Values("Set-Cookie"), []string{"a=b"})
+			assertDeepEqual(t, string(b), tt.exp)
+		})
+	}
+
+	t.Run("should return listener errors", func(t *testing.T) {
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		l.Close()
+
+		err = chop.Wrap(handler).ServeLocal(l)
+		assertErrorExists(t, err, true)
+	})
+}
@@ -0,0 +1,95 @@
+package chop
+
+import "github.com/aws/aws-lambda-go/lambda"
+
+// Option represents a handler option
+type Option func(*Handler)
+
+// WithBinaryPolicy configures the handler to use the specified binary policy to determine whether
+// response bodies are base64 encoded
+// DefaultBinaryPolicy is used if not specified
+func WithBinaryPolicy(p BinaryPolicy) Option {
+	return func(h *Handler) {
+		h.binaryPolicy = p
+	}
+}
+
+// WithEventProcessor registers the specified event processor for the handler
+func WithEventProcessor(p EventProcessor, priority int) Option {
+	return func(h *Handler) {
+		h.RegisterEventProcessor(p, priority)
+	}
+}
+
+// WithPanicHandler configures the handler to use the specified panic handler
+// DefaultPanicHandler is used if not specified
+func WithPanicHandler(ph PanicHandler) Option {
+	return func(h *Handler) {
+		h.panicHandler = ph
+	}
+}
+
+// WithRepanic configures the handler to re-panic rather than recover from panics in the wrapped handler
+func WithRepanic() Option {
+	return func(h *Handler) {
+		h.repanic = true
+	}
+}
+
+// WithErrorHandler configures the handler to use the specified error handler
+// DefaultErrorHandler is used if not specified
+func WithErrorHandler(eh ErrorHandler) Option {
+	return func(h *Handler) {
+		h.errorHandler = eh
+	}
+}
+
+// WithPathRewriter configures the handler to rewrite request paths using the specified path rewriters
+// Path rewriters are applied in the order they are specified
+func WithPathRewriter(rws ...PathRewriter) Option {
+	return func(h *Handler) {
+		h.pathRewriters = append(h.pathRewriters, rws...)
+	}
+}
+
+// WithLambdaOptions configures the lambda options used when the handler is started
+func WithLambdaOptions(opts ...lambda.Option) Option {
+	return func(h *Handler) {
+		h.lambdaOptions = append(h.lambdaOptions, opts...)
+	}
+}
+
+// WithSimpleAuthorizerResponses configures authorizers to return simple responses for HTTP API payload version 2.0
+// IAM policy responses are returned if not specified
+func WithSimpleAuthorizerResponses() Option {
+	return func(h *Handler) {
+		h.simpleAuthorizerResponses = true
+	}
+}
+
+// WithLocalAddr configures the handler to be served locally over HTTP on the specified address
+// By default the handler is served locally on the address in the CHOP_LOCAL_ADDR environment variable,
+// or on :8080 if it is not running in a lambda environment
+func WithLocalAddr(addr string) Option {
+	return func(h *Handler) {
+		h.localAddr = addr
+	}
+}
+
+// WithLocalEvents configures locally served requests to be wrapped in synthetic API gateway http v2 events
+func WithLocalEvents() Option {
+	return func(h *Handler) {
+		h.localEvents = true
+	}
+}
+
+// WithRecorder configures the handler to record invocation payloads and responses to the specified sink
+// Redaction rules are applied to payloads and responses before they are recorded. Streamed responses are not recorded.
+func WithRecorder(sink RecordingSink, rules ...RedactionRule) Option {
+	return func(h *Handler) {
+		h.recorder = &recorder{
+			sink:  sink,
+			rules: rules,
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package chop_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/tidwall/gjson"
+
+	"github.com/stevecallear/chop/v2"
+)
+
+func TestWithBinaryPolicy(t *testing.T) {
+	h := chop.Wrap(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/pdf")
+		w.Write([]byte("body"))
+	}), chop.WithBinaryPolicy(chop.BinaryContentTypes("application/pdf")))
+
+	b, err := h.Invoke(context.Background(), []byte(apiGatewayProxyEventPayload))
+	assertErrorExists(t, err, false)
+
+	act := gjson.GetManyBytes(b, "body", "isBase64Encoded")
+	assertDeepEqual(t, act[0].String(), "Ym9keQ==")
+	assertDeepEqual(t, act[1].Bool(), true)
+}
+
+func TestWithEventProcessor(t *testing.T) {
+	h := chop.Wrap(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(chop.GetEvent(r).(string)))
+	}), chop.WithEventProcessor(newTestEventProcessor("custom", "requestContext.apiId"), chop.PriorityAPIGatewayProxy-1))
+
+	b, err := h.Invoke(context.Background(), []byte(apiGatewayProxyEventPayload))
+	assertErrorExists(t, err, false)
+
+	act := gjson.GetBytes(b, "body").String()
+	assertDeepEqual(t, act, "custom")
+}
+
+func TestWithLambdaOptions(t *testing.T) {
+	type contextKey struct{}
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		v, _ := r.Context().Value(contextKey{}).(string)
+		w.Write([]byte(v))
+	})
+
+	results := make(chan []byte, 1)
+	startRuntimeAPI(t, apiGatewayProxyEventPayload, func(r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		results <- b
+	})
+
+	go chop.Start(handler, chop.WithLambdaOptions(lambda.WithContext(context.WithValue(context.Background(), contextKey{}, "value"))))
+
+	select {
+	case b := <-results:
+		act := gjson.GetBytes(b, "body").String()
+		assertDeepEqual(t, act, "value")
+	case <-time.After(5 * time.Second):
+		t.Error("got timeout, expected response")
+	}
+}
@@ -0,0 +1,103 @@
+package chop
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// PathRewriter rewrites the URL path of the specified request before it is served
+type PathRewriter func(r *http.Request)
+
+// StripPrefix returns a path rewriter that removes the specified prefix from request paths
+// Paths that do not begin with the prefix are not modified
+func StripPrefix(prefix string) PathRewriter {
+	prefix = "/" + strings.Trim(prefix, "/")
+
+	return func(r *http.Request) {
+		stripPrefix(r, prefix)
+	}
+}
+
+// StripStage returns a path rewriter that removes the API gateway stage from request paths
+// The $default stage is not removed
+func StripStage() PathRewriter {
+	return func(r *http.Request) {
+		info, _ := GetRequestInfo(r)
+		if info.Stage == "" || info.Stage == "$default" {
+			return
+		}
+
+		stripPrefix(r, "/"+info.Stage)
+	}
+}
+
+// RequestContextPath returns a path rewriter that uses the full request context path rather than the
+// resource path, which includes the stage and any custom domain base path
+func RequestContextPath() PathRewriter {
+	return func(r *http.Request) {
+		var path string
+		switch e := GetEvent(r).(type) {
+		case *events.APIGatewayProxyRequest:
+			path = e.RequestContext.Path
+		case *events.APIGatewayV2HTTPRequest:
+			path = e.RequestContext.HTTP.Path
+		}
+
+		if path == "" {
+			return
+		}
+
+		u, err := url.Parse(path)
+		if err != nil {
+			return
+		}
+
+		setPath(r, u.Path, u.RawPath)
+	}
+}
+
+func stripPrefix(r *http.Request, prefix string) {
+	if prefix == "/" {
+		return
+	}
+
+	p, ok := trimPathPrefix(r.URL.Path, prefix)
+	if !ok {
+		return
+	}
+
+	rp := r.URL.RawPath
+	if rp != "" {
+		if rp, ok = trimPathPrefix(rp, prefix); !ok {
+			return
+		}
+	}
+
+	setPath(r, p, rp)
+}
+
+func trimPathPrefix(path, prefix string) (string, bool) {
+	if !strings.HasPrefix(path, prefix) {
+		return "", false
+	}
+
+	p := path[len(prefix):]
+	if p == "" {
+		return "/", true
+	}
+
+	if p[0] != '/' {
+		return "", false
+	}
+
+	return p, true
+}
+
+func setPath(r *http.Request, path, rawPath string) {
+	r.URL.Path = path
+	r.URL.RawPath = rawPath
+	r.RequestURI = r.URL.RequestURI()
+}
@@ -0,0 +1,114 @@
+package chop_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stevecallear/chop/v2"
+)
+
+func TestWithPathRewriter(t *testing.T) {
+	type path struct {
+		path       string
+		rawPath    string
+		requestURI string
+	}
+
+	tests := []struct {
+		name      string
+		rewriters []chop.PathRewriter
+		payload   string
+		exp       path
+	}{
+		{
+			name:      "should strip the prefix",
+			rewriters: []chop.PathRewriter{chop.StripPrefix("/v1/")},
+			payload:   `{"httpMethod":"GET","path":"/v1/resource","queryStringParameters":{"q":"v"},"requestContext":{"apiId":"id"}}`,
+			exp:       path{path: "/resource", requestURI: "/resource?q=v"},
+		},
+		{
+			name:      "should strip the prefix from the root path",
+			rewriters: []chop.PathRewriter{chop.StripPrefix("v1")},
+			payload:   `{"httpMethod":"GET","path":"/v1","requestContext":{"apiId":"id"}}`,
+			exp:       path{path: "/", requestURI: "/"},
+		},
+		{
+			name:      "should not strip partial segments",
+			rewriters: []chop.PathRewriter{chop.StripPrefix("/v1")},
+			payload:   `{"httpMethod":"GET","path":"/v1resource","requestContext":{"apiId":"id"}}`,
+			exp:       path{path: "/v1resource", requestURI: "/v1resource"},
+		},
+		{
+			name:      "should not strip empty prefixes",
+			rewriters: []chop.PathRewriter{chop.StripPrefix("/")},
+			payload:   `{"httpMethod":"GET","path":"/v1/resource","requestContext":{"apiId":"id"}}`,
+			exp:       path{path: "/v1/resource", requestURI: "/v1/resource"},
+		},
+		{
+			name:      "should preserve the raw path",
+			rewriters: []chop.PathRewriter{chop.StripPrefix("/v1")},
+			payload:   `{"httpMethod":"GET","path":"/v1/a%2Fb","requestContext":{"apiId":"id"}}`,
+			exp:       path{path: "/a/b", rawPath: "/a%2Fb", requestURI: "/a%2Fb"},
+		},
+		{
+			name:      "should strip the api gateway proxy stage",
+			rewriters: []chop.PathRewriter{chop.StripStage()},
+			payload:   `{"httpMethod":"GET","path":"/dev/resource","requestContext":{"apiId":"id","stage":"dev"}}`,
+			exp:       path{path: "/resource", requestURI: "/resource"},
+		},
+		{
+			name:      "should strip the api gateway http v2 stage",
+			rewriters: []chop.PathRewriter{chop.StripStage()},
+			payload:   `{"version":"2.0","rawPath":"/dev/resource","requestContext":{"apiId":"id","stage":"dev","http":{"method":"GET"}}}`,
+			exp:       path{path: "/resource", requestURI: "/resource"},
+		},
+		{
+			name:      "should not strip the default stage",
+			rewriters: []chop.PathRewriter{chop.StripStage()},
+			payload:   `{"version":"2.0","rawPath":"/$default/resource","requestContext":{"apiId":"id","stage":"$default","http":{"method":"GET"}}}`,
+			exp:       path{path: "/$default/resource", requestURI: "/$default/resource"},
+		},
+		{
+			name:      "should ignore the stage for other event types",
+			rewriters: []chop.PathRewriter{chop.StripStage()},
+			payload:   `{"httpMethod":"GET","path":"/dev/resource","requestContext":{"elb":{}}}`,
+			exp:       path{path: "/dev/resource", requestURI: "/dev/resource"},
+		},
+		{
+			name:      "should use the api gateway proxy request context path",
+			rewriters: []chop.PathRewriter{chop.RequestContextPath()},
+			payload:   `{"httpMethod":"GET","path":"/resource","requestContext":{"apiId":"id","stage":"dev","path":"/v1/dev/a%2Fb"}}`,
+			exp:       path{path: "/v1/dev/a/b", rawPath: "/v1/dev/a%2Fb", requestURI: "/v1/dev/a%2Fb"},
+		},
+		{
+			name:      "should use the api gateway http v2 request context path",
+			rewriters: []chop.PathRewriter{chop.RequestContextPath()},
+			payload:   `{"version":"2.0","rawPath":"/resource","requestContext":{"apiId":"id","http":{"method":"GET","path":"/v1/resource"}}}`,
+			exp:       path{path: "/v1/resource", requestURI: "/v1/resource"},
+		},
+		{
+			name:      "should apply rewriters in order",
+			rewriters: []chop.PathRewriter{chop.RequestContextPath(), chop.StripPrefix("/v1"), chop.StripStage()},
+			payload:   `{"httpMethod":"GET","path":"/resource","requestContext":{"apiId":"id","stage":"dev","path":"/v1/dev/resource"}}`,
+			exp:       path{path: "/resource", requestURI: "/resource"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var act path
+			h := chop.Wrap(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				act = path{
+					path:       r.URL.Path,
+					rawPath:    r.URL.RawPath,
+					requestURI: r.RequestURI,
+				}
+			}), chop.WithPathRewriter(tt.rewriters...))
+
+			_, err := h.Invoke(context.Background(), []byte(tt.payload))
+			assertErrorExists(t, err, false)
+			assertDeepEqual(t, act, tt.exp)
+		})
+	}
+}
@@ -0,0 +1,681 @@
+package chop
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/tidwall/gjson"
+)
+
+type (
+	// EventProcessor represents a lambda event processor
+	EventProcessor interface {
+		// CanProcess returns true if the processor can process the specified payload
+		CanProcess(payload []byte) bool
+
+		// UnmarshalRequest unmarshals the specified payload into an HTTP request
+		UnmarshalRequest(ctx context.Context, payload []byte) (*http.Request, error)
+
+		// MarshalResponse marshals the specified response writer into a response payload
+		MarshalResponse(w *ResponseWriter) ([]byte, error)
+	}
+
+	eventProcessor struct {
+		canProcess       func([]byte) bool
+		unmarshalRequest func(context.Context, []byte) (*http.Request, error)
+		marshalResponse  func(*ResponseWriter) ([]byte, error)
+	}
+
+	lambdaFunctionURLResponsePrelude struct {
+		StatusCode int               `json:"statusCode"`
+		Headers    map[string]string `json:"headers"`
+		Cookies    []string          `json:"cookies"`
+	}
+
+	registry struct {
+		mu      sync.RWMutex
+		entries []registryEntry
+	}
+
+	registryEntry struct {
+		processor EventProcessor
+		priority  int
+	}
+)
+
+// Built-in event processor priorities
+const (
+	PriorityAPIGatewayWebsocket = 50
+	PriorityAPIGatewayProxy     = 100
+	PriorityLambdaFunctionURL   = 150
+	PriorityAPIGatewayV2HTTP    = 200
+	PriorityALBTargetGroup      = 300
+	PriorityVPCLattice          = 400
+	PriorityVPCLatticeV2        = 450
+	PriorityCloudFront          = 500
+)
+
+var (
+	// ErrUnsupportedEventType indicates that the received lambda event is not supported
+	ErrUnsupportedEventType = errors.New("unsupported lambda event type")
+
+	apiGatewayProxyEventProcessor = &eventProcessor{
+		canProcess: func(payload []byte) bool {
+			pv := gjson.GetManyBytes(payload, "version", "requestContext.apiId")
+			return !pv[0].Exists() && pv[1].Exists()
+		},
+		unmarshalRequest: func(ctx context.Context, payload []byte) (*http.Request, error) {
+			e := new(events.APIGatewayProxyRequest)
+			if err := json.Unmarshal(payload, e); err != nil {
+				return nil, err
+			}
+
+			r, err := newRequest(e.HTTPMethod, e.Path, e.Body, e.IsBase64Encoded)
+			if err != nil {
+				return nil, err
+			}
+
+			q := r.URL.Query()
+			addMapValues(e.QueryStringParameters, e.MultiValueQueryStringParameters, q.Add)
+			r.URL.RawQuery = q.Encode()
+
+			addMapValues(e.Headers, e.MultiValueHeaders, r.Header.Add)
+
+			setRequestFields(r, e.RequestContext.Identity.SourceIP, e.RequestContext.DomainName, e.RequestContext.Protocol)
+
+			r = WithRoute(r.WithContext(ctx), Route{Template: e.Resource, Parameters: e.PathParameters})
+			r = WithRequestInfo(r, RequestInfo{
+				Kind:       KindAPIGatewayProxy,
+				RequestID:  e.RequestContext.RequestID,
+				APIID:      e.RequestContext.APIID,
+				Stage:      e.RequestContext.Stage,
+				RouteKey:   e.Resource,
+				SourceIP:   e.RequestContext.Identity.SourceIP,
+				UserAgent:  e.RequestContext.Identity.UserAgent,
+				DomainName: e.RequestContext.DomainName,
+				Time:       epochMillis(e.RequestContext.RequestTimeEpoch),
+				Authorizer: authorizerContext(payload),
+			})
+
+			return WithEvent(r, e), nil
+		},
+		marshalResponse: marshalAPIGatewayProxyResponse,
+	}
+
+	apiGatewayWebsocketEventProcessor = &eventProcessor{
+		canProcess: func(payload []byte) bool {
+			pv := gjson.GetManyBytes(payload, "requestContext.connectionId", "requestContext.eventType")
+			return pv[0].Exists() && pv[1].Exists()
+		},
+		unmarshalRequest: func(ctx context.Context, payload []byte) (*http.Request, error) {
+			e := new(events.APIGatewayWebsocketProxyRequest)
+			if err := json.Unmarshal(payload, e); err != nil {
+				return nil, err
+			}
+
+			r, err := newRequest(http.MethodPost, "/"+e.RequestContext.RouteKey, e.Body, e.IsBase64Encoded)
+			if err != nil {
+				return nil, err
+			}
+
+			q := r.URL.Query()
+			addMapValues(e.QueryStringParameters, e.MultiValueQueryStringParameters, q.Add)
+			r.URL.RawQuery = q.Encode()
+
+			addMapValues(e.Headers, e.MultiValueHeaders, r.Header.Add)
+
+			setRequestFields(r, e.RequestContext.Identity.SourceIP, e.RequestContext.DomainName, "")
+
+			r = WithWebSocketConnection(r.WithContext(ctx), WebSocketConnection{
+				ID:          e.RequestContext.ConnectionID,
+				EventType:   e.RequestContext.EventType,
+				Endpoint:    connectionEndpoint(e.RequestContext.DomainName, e.RequestContext.Stage),
+				ConnectedAt: epochMillis(e.RequestContext.ConnectedAt),
+			})
+			r = WithRequestInfo(r, RequestInfo{
+				Kind:       KindAPIGatewayWebsocket,
+				RequestID:  e.RequestContext.RequestID,
+				APIID:      e.RequestContext.APIID,
+				Stage:      e.RequestContext.Stage,
+				RouteKey:   e.RequestContext.RouteKey,
+				SourceIP:   e.RequestContext.Identity.SourceIP,
+				UserAgent:  e.RequestContext.Identity.UserAgent,
+				DomainName: e.RequestContext.DomainName,
+				Time:       epochMillis(e.RequestContext.RequestTimeEpoch),
+				Authorizer: authorizerContext(payload),
+			})
+
+			return WithEvent(r, e), nil
+		},
+		marshalResponse: marshalAPIGatewayProxyResponse,
+	}
+
+	lambdaFunctionURLEventProcessor = &streamingEventProcessor{
+		eventProcessor: &eventProcessor{
+			canProcess: func(payload []byte) bool {
+				pv := gjson.GetManyBytes(payload, "version", "requestContext.domainName")
+				return pv[0].String() == "2.0" && strings.Contains(pv[1].String(), ".lambda-url.")
+			},
+			unmarshalRequest: func(ctx context.Context, payload []byte) (*http.Request, error) {
+				e := new(events.LambdaFunctionURLRequest)
+				if err := json.Unmarshal(payload, e); err != nil {
+					return nil, err
+				}
+
+				r, err := newRequest(e.RequestContext.HTTP.Method, e.RawPath, e.Body, e.IsBase64Encoded)
+				if err != nil {
+					return nil, err
+				}
+
+				setQuery(r, e.RawQueryString, e.QueryStringParameters)
+
+				addMapValues(e.Headers, nil, r.Header.Add)
+				addCookies(e.Cookies, r.Header)
+
+				setRequestFields(r, e.RequestContext.HTTP.SourceIP, e.RequestContext.DomainName, e.RequestContext.HTTP.Protocol)
+
+				r = WithRequestInfo(r.WithContext(ctx), RequestInfo{
+					Kind:       KindLambdaFunctionURL,
+					RequestID:  e.RequestContext.RequestID,
+					APIID:      e.RequestContext.APIID,
+					SourceIP:   e.RequestContext.HTTP.SourceIP,
+					UserAgent:  e.RequestContext.HTTP.UserAgent,
+					DomainName: e.RequestContext.DomainName,
+					Time:       epochMillis(e.RequestContext.TimeEpoch),
+					Authorizer: authorizerContext(payload),
+				})
+
+				return WithEvent(r, e), nil
+			},
+			marshalResponse: func(w *ResponseWriter) ([]byte, error) {
+				body, isBase64Encoded := w.EncodedBody()
+				h, cookies := splitCookies(w.Header())
+
+				return json.Marshal(&events.LambdaFunctionURLResponse{
+					StatusCode:      w.StatusCode(),
+					Headers:         joinHeaders(h),
+					Body:            body,
+					IsBase64Encoded: isBase64Encoded,
+					Cookies:         cookies,
+				})
+			},
+		},
+		marshalResponsePrelude: func(w *ResponseWriter) ([]byte, error) {
+			h, cookies := splitCookies(w.Header())
+
+			return json.Marshal(&lambdaFunctionURLResponsePrelude{
+				StatusCode: w.StatusCode(),
+				Headers:    joinHeaders(h),
+				Cookies:    cookies,
+			})
+		},
+	}
+
+	apiGatewayV2HTTPEventProcessor = &eventProcessor{
+		canProcess: func(payload []byte) bool {
+			pv := gjson.GetManyBytes(payload, "version", "requestContext.apiId")
+			return pv[0].String() == "2.0" && pv[1].Exists()
+		},
+		unmarshalRequest: func(ctx context.Context, payload []byte) (*http.Request, error) {
+			e := new(events.APIGatewayV2HTTPRequest)
+			if err := json.Unmarshal(payload, e); err != nil {
+				return nil, err
+			}
+
+			r, err := newRequest(e.RequestContext.HTTP.Method, e.RawPath, e.Body, e.IsBase64Encoded)
+			if err != nil {
+				return nil, err
+			}
+
+			setQuery(r, e.RawQueryString, e.QueryStringParameters)
+
+			addMapValues(e.Headers, nil, r.Header.Add)
+			addCookies(e.Cookies, r.Header)
+
+			setRequestFields(r, e.RequestContext.HTTP.SourceIP, e.RequestContext.DomainName, e.RequestContext.HTTP.Protocol)
+
+			r = WithRoute(r.WithContext(ctx), Route{Template: routeTemplate(e.RouteKey), Parameters: e.PathParameters})
+			r = WithRequestInfo(r, RequestInfo{
+				Kind:       KindAPIGatewayV2HTTP,
+				RequestID:  e.RequestContext.RequestID,
+				APIID:      e.RequestContext.APIID,
+				Stage:      e.RequestContext.Stage,
+				RouteKey:   e.RouteKey,
+				SourceIP:   e.RequestContext.HTTP.SourceIP,
+				UserAgent:  e.RequestContext.HTTP.UserAgent,
+				DomainName: e.RequestContext.DomainName,
+				Time:       epochMillis(e.RequestContext.TimeEpoch),
+				Authorizer: authorizerContext(payload),
+			})
+
+			return WithEvent(r, e), nil
+		},
+		marshalResponse: func(w *ResponseWriter) ([]byte, error) {
+			body, isBase64Encoded := w.EncodedBody()
+			h, cookies := splitCookies(w.Header())
+
+			return json.Marshal(&events.APIGatewayV2HTTPResponse{
+				StatusCode:        w.StatusCode(),
+				Headers:           joinHeaders(h),
+				MultiValueHeaders: h,
+				Body:              body,
+				IsBase64Encoded:   isBase64Encoded,
+				Cookies:           cookies,
+			})
+		},
+	}
+
+	albTargetGroupEventProcessor = &eventProcessor{
+		canProcess: func(payload []byte) bool {
+			return gjson.GetBytes(payload, "requestContext.elb").Exists()
+		},
+		unmarshalRequest: func(ctx context.Context, payload []byte) (*http.Request, error) {
+			e := new(events.ALBTargetGroupRequest)
+			if err := json.Unmarshal(payload, e); err != nil {
+				return nil, err
+			}
+
+			r, err := newRequest(e.HTTPMethod, e.Path, e.Body, e.IsBase64Encoded)
+			if err != nil {
+				return nil, err
+			}
+
+			q := r.URL.Query()
+			addMapValues(e.QueryStringParameters, e.MultiValueQueryStringParameters, q.Add)
+			r.URL.RawQuery = q.Encode()
+
+			addMapValues(e.Headers, e.MultiValueHeaders, r.Header.Add)
+
+			setRequestFields(r, forwardedFor(r.Header), "", "")
+
+			r = WithRequestInfo(r.WithContext(ctx), RequestInfo{
+				Kind:       KindALBTargetGroup,
+				SourceIP:   r.RemoteAddr,
+				UserAgent:  r.UserAgent(),
+				DomainName: r.Host,
+			})
+
+			return WithEvent(r, e), nil
+		},
+		marshalResponse: func(w *ResponseWriter) ([]byte, error) {
+			body, isBase64Encoded := w.EncodedBody()
+
+			return json.Marshal(&events.ALBTargetGroupResponse{
+				StatusCode:        w.StatusCode(),
+				StatusDescription: w.Status(),
+				Headers:           reduceHeaders(w.Header()),
+				MultiValueHeaders: w.Header(),
+				Body:              body,
+				IsBase64Encoded:   isBase64Encoded,
+			})
+		},
+	}
+
+	vpcLatticeEventProcessor = &eventProcessor{
+		canProcess: func(payload []byte) bool {
+			pv := gjson.GetManyBytes(payload, "raw_path", "method")
+			return pv[0].Exists() && pv[1].Exists()
+		},
+		unmarshalRequest: func(ctx context.Context, payload []byte) (*http.Request, error) {
+			e := new(VPCLatticeRequest)
+			if err := json.Unmarshal(payload, e); err != nil {
+				return nil, err
+			}
+
+			r, err := newRequest(e.Method, e.RawPath, e.Body, e.IsBase64Encoded)
+			if err != nil {
+				return nil, err
+			}
+
+			if len(e.QueryStringParameters) > 0 {
+				q := r.URL.Query()
+				addMapValues(e.QueryStringParameters, nil, q.Add)
+				r.URL.RawQuery = q.Encode()
+			}
+
+			addMapValues(e.Headers, nil, r.Header.Add)
+
+			setRequestFields(r, forwardedFor(r.Header), "", "")
+
+			r = WithRequestInfo(r.WithContext(ctx), RequestInfo{
+				Kind:       KindVPCLattice,
+				SourceIP:   r.RemoteAddr,
+				UserAgent:  r.UserAgent(),
+				DomainName: r.Host,
+			})
+
+			return WithEvent(r, e), nil
+		},
+		marshalResponse: marshalVPCLatticeResponse,
+	}
+
+	vpcLatticeV2EventProcessor = &eventProcessor{
+		canProcess: func(payload []byte) bool {
+			pv := gjson.GetManyBytes(payload, "version", "requestContext.serviceNetworkArn", "requestContext.serviceArn")
+			return pv[0].String() == "2.0" && (pv[1].Exists() || pv[2].Exists())
+		},
+		unmarshalRequest: func(ctx context.Context, payload []byte) (*http.Request, error) {
+			e := new(VPCLatticeRequestV2)
+			if err := json.Unmarshal(payload, e); err != nil {
+				return nil, err
+			}
+
+			r, err := newRequest(e.Method, e.Path, e.Body, e.IsBase64Encoded)
+			if err != nil {
+				return nil, err
+			}
+
+			if len(e.QueryStringParameters) > 0 {
+				q := r.URL.Query()
+				addMultiValues(e.QueryStringParameters, q.Add)
+				r.URL.RawQuery = q.Encode()
+			}
+
+			addMultiValues(e.Headers, r.Header.Add)
+
+			setRequestFields(r, forwardedFor(r.Header), "", "")
+
+			r = WithRequestInfo(r.WithContext(ctx), RequestInfo{
+				Kind:       KindVPCLatticeV2,
+				SourceIP:   r.RemoteAddr,
+				UserAgent:  r.UserAgent(),
+				DomainName: r.Host,
+				Time:       epochMicros(e.RequestContext.TimeEpoch),
+			})
+
+			return WithEvent(r, e), nil
+		},
+		marshalResponse: marshalVPCLatticeResponse,
+	}
+
+	cloudFrontEventProcessor = &eventProcessor{
+		canProcess: func(payload []byte) bool {
+			pv := gjson.GetManyBytes(payload, "Records.0.cf.request", "Records.0.cf.response")
+			return pv[0].Exists() && !pv[1].Exists()
+		},
+		unmarshalRequest: func(ctx context.Context, payload []byte) (*http.Request, error) {
+			e := new(CloudFrontEvent)
+			if err := json.Unmarshal(payload, e); err != nil {
+				return nil, err
+			}
+
+			cf := e.Records[0].CF
+
+			var body string
+			var isBase64Encoded bool
+			if b := cf.Request.Body; b != nil {
+				body, isBase64Encoded = b.Data, b.Encoding == "base64"
+			}
+
+			u := cf.Request.URI
+			if cf.Request.QueryString != "" {
+				u += "?" + cf.Request.QueryString
+			}
+
+			r, err := newRequest(cf.Request.Method, u, body, isBase64Encoded)
+			if err != nil {
+				return nil, err
+			}
+
+			addCloudFrontHeaders(cf.Request.Headers, r.Header)
+
+			setRequestFields(r, cf.Request.ClientIP, cf.Config.DistributionDomainName, "")
+
+			r = WithRequestInfo(r.WithContext(ctx), RequestInfo{
+				Kind:       KindCloudFront,
+				RequestID:  cf.Config.RequestID,
+				SourceIP:   cf.Request.ClientIP,
+				UserAgent:  r.UserAgent(),
+				DomainName: cf.Config.DistributionDomainName,
+			})
+
+			return WithEvent(r, e), nil
+		},
+		marshalResponse: func(w *ResponseWriter) ([]byte, error) {
+			if w.forward != nil {
+				return json.Marshal(newCloudFrontRequest(w.forward))
+			}
+
+			return json.Marshal(newCloudFrontResponse(w))
+		},
+	}
+
+	defaultRegistry = newRegistry(
+		registryEntry{processor: apiGatewayWebsocketEventProcessor, priority: PriorityAPIGatewayWebsocket},
+		registryEntry{processor: apiGatewayProxyEventProcessor, priority: PriorityAPIGatewayProxy},
+		registryEntry{processor: lambdaFunctionURLEventProcessor, priority: PriorityLambdaFunctionURL},
+		registryEntry{processor: apiGatewayV2HTTPEventProcessor, priority: PriorityAPIGatewayV2HTTP},
+		registryEntry{processor: albTargetGroupEventProcessor, priority: PriorityALBTargetGroup},
+		registryEntry{processor: vpcLatticeEventProcessor, priority: PriorityVPCLattice},
+		registryEntry{processor: vpcLatticeV2EventProcessor, priority: PriorityVPCLatticeV2},
+		registryEntry{processor: cloudFrontEventProcessor, priority: PriorityCloudFront},
+	)
+)
+
+// RegisterEventProcessor registers the specified event processor for all handlers
+// Processors are evaluated in ascending priority order, with processors of the same
+// priority evaluated in registration order
+func RegisterEventProcessor(p EventProcessor, priority int) {
+	defaultRegistry.register(p, priority)
+}
+
+func (p *eventProcessor) CanProcess(payload []byte) bool {
+	return p.canProcess(payload)
+}
+
+func (p *eventProcessor) UnmarshalRequest(ctx context.Context, payload []byte) (*http.Request, error) {
+	return p.unmarshalRequest(ctx, payload)
+}
+
+func (p *eventProcessor) MarshalResponse(w *ResponseWriter) ([]byte, error) {
+	return p.marshalResponse(w)
+}
+
+func marshalAPIGatewayProxyResponse(w *ResponseWriter) ([]byte, error) {
+	body, isBase64Encoded := w.EncodedBody()
+
+	return json.Marshal(&events.APIGatewayProxyResponse{
+		StatusCode:        w.StatusCode(),
+		Headers:           reduceHeaders(w.Header()),
+		MultiValueHeaders: w.Header(),
+		Body:              body,
+		IsBase64Encoded:   isBase64Encoded,
+	})
+}
+
+func marshalVPCLatticeResponse(w *ResponseWriter) ([]byte, error) {
+	body, isBase64Encoded := w.EncodedBody()
+
+	return json.Marshal(&VPCLatticeResponse{
+		StatusCode:        w.StatusCode(),
+		StatusDescription: w.Status(),
+		Headers:           joinHeaders(w.Header()),
+		Body:              body,
+		IsBase64Encoded:   isBase64Encoded,
+	})
+}
+
+func newRegistry(entries ...registryEntry) *registry {
+	r := new(registry)
+	for _, e := range entries {
+		r.register(e.processor, e.priority)
+	}
+
+	return r
+}
+
+func (r *registry) register(p EventProcessor, priority int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	i := sort.Search(len(r.entries), func(i int) bool {
+		return r.entries[i].priority > priority
+	})
+
+	r.entries = append(r.entries, registryEntry{})
+	copy(r.entries[i+1:], r.entries[i:])
+	r.entries[i] = registryEntry{processor: p, priority: priority}
+}
+
+func (r *registry) get() []registryEntry {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return append([]registryEntry(nil), r.entries...)
+}
+
+func mergeProcessors(primary, secondary []registryEntry) []EventProcessor {
+	ps := make([]EventProcessor, 0, len(primary)+len(secondary))
+	for len(primary) > 0 || len(secondary) > 0 {
+		if len(secondary) == 0 || (len(primary) > 0 && primary[0].priority <= secondary[0].priority) {
+			ps = append(ps, primary[0].processor)
+			primary = primary[1:]
+			continue
+		}
+
+		ps = append(ps, secondary[0].processor)
+		secondary = secondary[1:]
+	}
+
+	return ps
+}
+
+func newRequest(method, path, body string, isBase64Encoded bool) (*http.Request, error) {
+	if !isBase64Encoded {
+		return http.NewRequest(method, path, strings.NewReader(body))
+	}
+
+	d := base64.NewDecoder(base64.StdEncoding, strings.NewReader(body))
+
+	r, err := http.NewRequest(method, path, d)
+	if err != nil {
+		return nil, err
+	}
+
+	pad := len(body) - len(strings.TrimRight(body, "="))
+	r.ContentLength = int64(base64.StdEncoding.DecodedLen(len(body)) - pad)
+
+	return r, nil
+}
+
+func setRequestFields(r *http.Request, sourceIP, domainName, protocol string) {
+	r.RemoteAddr = sourceIP
+
+	if h := r.Header.Get("Host"); h != "" {
+		r.Host = h
+		r.Header.Del("Host")
+	} else {
+		r.Host = domainName
+	}
+
+	if major, minor, ok := http.ParseHTTPVersion(protocol); ok {
+		r.Proto, r.ProtoMajor, r.ProtoMinor = protocol, major, minor
+	}
+
+	if strings.EqualFold(r.Header.Get("X-Forwarded-Proto"), "https") {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+
+		r.TLS = &tls.ConnectionState{
+			HandshakeComplete: true,
+			ServerName:        host,
+		}
+	}
+
+	r.RequestURI = r.URL.RequestURI()
+}
+
+// forwardedFor returns the last address in the X-Forwarded-For header, which is the address
+// of the client that connected to the load balancer
+func forwardedFor(h http.Header) string {
+	vs := strings.Split(strings.Join(h.Values("X-Forwarded-For"), ","), ",")
+	return strings.TrimSpace(vs[len(vs)-1])
+}
+
+// setQuery sets the request query to the raw query string if it exists, otherwise the query is
+// built from the comma separated query string parameters
+func setQuery(r *http.Request, rawQuery string, values map[string]string) {
+	if rawQuery != "" {
+		r.URL.RawQuery = rawQuery
+		return
+	}
+
+	q := r.URL.Query()
+	addSeparatedValues(values, q.Add)
+	r.URL.RawQuery = q.Encode()
+}
+
+// addMapValues adds the multi-value map values if they exist, otherwise the single value map values are added
+func addMapValues(values map[string]string, multiValues map[string][]string, addFn func(string, string)) {
+	if len(multiValues) > 0 {
+		addMultiValues(multiValues, addFn)
+		return
+	}
+
+	for k, v := range values {
+		addFn(k, v)
+	}
+}
+
+func addMultiValues(values map[string][]string, addFn func(string, string)) {
+	for k, vs := range values {
+		for _, v := range vs {
+			addFn(k, v)
+		}
+	}
+}
+
+func addSeparatedValues(values map[string]string, addFn func(string, string)) {
+	for k, p := range values {
+		for _, v := range strings.Split(p, ",") {
+			addFn(k, v)
+		}
+	}
+}
+
+func addCookies(cookies []string, h http.Header) {
+	if len(cookies) > 0 {
+		h.Add("Cookie", strings.Join(cookies, "; "))
+	}
+}
+
+func splitCookies(h http.Header) (http.Header, []string) {
+	cookies, ok := h["Set-Cookie"]
+	if !ok {
+		return h, []string{}
+	}
+
+	h = h.Clone()
+	h.Del("Set-Cookie")
+
+	return h, cookies
+}
+
+func reduceHeaders(h http.Header) map[string]string {
+	m := make(map[string]string, len(h))
+	for k := range h {
+		m[k] = h.Get(k)
+	}
+
+	return m
+}
+
+func joinHeaders(h http.Header) map[string]string {
+	m := make(map[string]string, len(h))
+	for k, vs := range h {
+		m[k] = strings.Join(vs, ",")
+	}
+
+	return m
+}
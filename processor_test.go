@@ -0,0 +1,197 @@
+package chop_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/tidwall/gjson"
+
+	"github.com/stevecallear/chop/v2"
+)
+
+func TestRegisterEventProcessor(t *testing.T) {
+	chop.IsolateDefaultRegistry(t)
+	chop.RegisterEventProcessor(newTestEventProcessor("global", "requestContext.global"), chop.PriorityAPIGatewayProxy-1)
+
+	tests := []struct {
+		name       string
+		processors []registration
+		payload    string
+		exp        string
+	}{
+		{
+			name:    "should use global processors",
+			payload: `{"requestContext":{"apiId":"id","global":true}}`,
+			exp:     "global",
+		},
+		{
+			name:    "should use built-in processors",
+			payload: `{"requestContext":{"apiId":"id"}}`,
+			exp:     "",
+		},
+		{
+			name: "should use handler processors",
+			processors: []registration{
+				{processor: newTestEventProcessor("handler", "requestContext.handler"), priority: chop.PriorityAPIGatewayProxy + 1},
+			},
+			payload: `{"requestContext":{"handler":true}}`,
+			exp:     "handler",
+		},
+		{
+			name: "should evaluate processors in priority order",
+			processors: []registration{
+				{processor: newTestEventProcessor("low", "requestContext.apiId"), priority: chop.PriorityAPIGatewayProxy + 1},
+				{processor: newTestEventProcessor("high", "requestContext.apiId"), priority: chop.PriorityAPIGatewayProxy - 2},
+			},
+			payload: `{"requestContext":{"apiId":"id","global":true}}`,
+			exp:     "high",
+		},
+		{
+			name: "should evaluate handler processors before global processors of the same priority",
+			processors: []registration{
+				{processor: newTestEventProcessor("handler", "requestContext.global"), priority: chop.PriorityAPIGatewayProxy - 1},
+			},
+			payload: `{"requestContext":{"apiId":"id","global":true}}`,
+			exp:     "handler",
+		},
+		{
+			name: "should evaluate processors of the same priority in registration order",
+			processors: []registration{
+				{processor: newTestEventProcessor("first", "requestContext.handler"), priority: 0},
+				{processor: newTestEventProcessor("second", "requestContext.handler"), priority: 0},
+			},
+			payload: `{"requestContext":{"handler":true}}`,
+			exp:     "first",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := chop.Wrap(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if s, ok := chop.GetEvent(r).(string); ok {
+					w.Write([]byte(s))
+				}
+			}))
+
+			for _, r := range tt.processors {
+				h.RegisterEventProcessor(r.processor, r.priority)
+			}
+
+			b, err := h.Invoke(context.Background(), []byte(tt.payload))
+			assertErrorExists(t, err, false)
+
+			act := gjson.GetBytes(b, "body").String()
+			assertDeepEqual(t, act, tt.exp)
+		})
+	}
+
+	t.Run("should discard global processors registered by the test", func(t *testing.T) {
+		chop.IsolateDefaultRegistry(t)
+		chop.RegisterEventProcessor(newTestEventProcessor("isolated", "requestContext.apiId"), chop.PriorityAPIGatewayProxy-1)
+	})
+
+	t.Run("should not use processors registered by other tests", func(t *testing.T) {
+		b, err := chop.Wrap(http.NotFoundHandler()).Invoke(context.Background(), []byte(`{"requestContext":{"apiId":"id"}}`))
+		assertErrorExists(t, err, false)
+		assertDeepEqual(t, gjson.GetBytes(b, "statusCode").Int(), int64(http.StatusNotFound))
+	})
+}
+
+func TestHandler_Invoke_Parameters(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload string
+		query   string
+		header  http.Header
+	}{
+		{
+			name:    "should use api gateway proxy multi-value maps with a single key",
+			payload: `{"httpMethod":"GET","path":"/","headers":{"Accept":"b"},"multiValueHeaders":{"Accept":["a","b"]},"queryStringParameters":{"q":"2"},"multiValueQueryStringParameters":{"q":["1","2"]},"requestContext":{"apiId":"id"}}`,
+			query:   "q=1&q=2",
+			header:  http.Header{"Accept": {"a", "b"}},
+		},
+		{
+			name:    "should use api gateway proxy single value maps if multi-value maps are empty",
+			payload: `{"httpMethod":"GET","path":"/","headers":{"Accept":"a"},"multiValueHeaders":{},"queryStringParameters":{"q":"1"},"requestContext":{"apiId":"id"}}`,
+			query:   "q=1",
+			header:  http.Header{"Accept": {"a"}},
+		},
+		{
+			name:    "should use alb target group multi-value maps with a single key",
+			payload: `{"httpMethod":"GET","path":"/","multiValueHeaders":{"accept":["a","b"]},"multiValueQueryStringParameters":{"q":["1","2"]},"requestContext":{"elb":{}}}`,
+			query:   "q=1&q=2",
+			header:  http.Header{"Accept": {"a", "b"}},
+		},
+		{
+			name:    "should use api gateway http v2 raw query strings",
+			payload: `{"version":"2.0","rawPath":"/","rawQueryString":"q=a%2Cb&q=c&r","routeKey":"$default","queryStringParameters":{"q":"a,b,c","r":""},"requestContext":{"apiId":"id","http":{"method":"GET"}}}`,
+			query:   "q=a%2Cb&q=c&r",
+			header:  http.Header{},
+		},
+		{
+			name:    "should split api gateway http v2 query string parameters without raw query strings",
+			payload: `{"version":"2.0","rawPath":"/","routeKey":"$default","queryStringParameters":{"q":"a,b"},"requestContext":{"apiId":"id","http":{"method":"GET"}}}`,
+			query:   "q=a&q=b",
+			header:  http.Header{},
+		},
+		{
+			name:    "should use lambda function url raw query strings",
+			payload: `{"version":"2.0","rawPath":"/","rawQueryString":"q=a%2Cb","queryStringParameters":{"q":"a,b"},"requestContext":{"apiId":"urlid","domainName":"urlid.lambda-url.eu-west-1.on.aws","http":{"method":"GET"}}}`,
+			query:   "q=a%2Cb",
+			header:  http.Header{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var query string
+			var header http.Header
+			h := chop.Wrap(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				query, header = r.URL.RawQuery, r.Header
+			}))
+
+			_, err := h.Invoke(context.Background(), []byte(tt.payload))
+			assertErrorExists(t, err, false)
+			assertDeepEqual(t, query, tt.query)
+			assertDeepEqual(t, header, tt.header)
+		})
+	}
+}
+
+type (
+	registration struct {
+		processor chop.EventProcessor
+		priority  int
+	}
+
+	testEventProcessor struct {
+		name string
+		path string
+	}
+)
+
+func newTestEventProcessor(name, path string) *testEventProcessor {
+	return &testEventProcessor{name: name, path: path}
+}
+
+func (p *testEventProcessor) CanProcess(payload []byte) bool {
+	return gjson.GetBytes(payload, p.path).Exists()
+}
+
+func (p *testEventProcessor) UnmarshalRequest(ctx context.Context, payload []byte) (*http.Request, error) {
+	r, err := http.NewRequest(http.MethodGet, "/", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return chop.WithEvent(r.WithContext(ctx), p.name), nil
+}
+
+func (p *testEventProcessor) MarshalResponse(w *chop.ResponseWriter) ([]byte, error) {
+	return json.Marshal(map[string]interface{}{
+		"statusCode": w.StatusCode(),
+		"body":       w.Body(),
+	})
+}
@@ -0,0 +1,245 @@
+package chop
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-lambda-go/lambdacontext"
+)
+
+type (
+	// Recording represents a recorded lambda invocation
+	Recording struct {
+		Time      time.Time       `json:"time"`
+		RequestID string          `json:"requestId,omitempty"`
+		Payload   json.RawMessage `json:"payload"`
+		Response  json.RawMessage `json:"response,omitempty"`
+		Error     string          `json:"error,omitempty"`
+	}
+
+	// RecordingSink represents a destination for recorded invocations
+	RecordingSink interface {
+		Record(ctx context.Context, rec *Recording) error
+	}
+
+	// RecordingSinkFunc represents a function that can be used as a recording sink
+	RecordingSinkFunc func(ctx context.Context, rec *Recording) error
+
+	// RedactionRule represents a rule that redacts sensitive values from a decoded payload or response
+	RedactionRule func(v map[string]interface{})
+
+	// MemorySink represents an in-memory recording sink
+	MemorySink struct {
+		mu         sync.Mutex
+		recordings []*Recording
+	}
+
+	recorder struct {
+		sink  RecordingSink
+		rules []RedactionRule
+	}
+
+	writerSink struct {
+		mu sync.Mutex
+		w  io.Writer
+	}
+)
+
+// RedactedValue is the value used to replace redacted header and body values
+const RedactedValue = "REDACTED"
+
+// Record records the invocation using the function
+func (fn RecordingSinkFunc) Record(ctx context.Context, rec *Recording) error {
+	return fn(ctx, rec)
+}
+
+// NewWriterSink returns a recording sink that writes recordings to the writer as newline delimited JSON
+// Using os.Stdout writes recordings to CloudWatch logs when running in a lambda environment
+func NewWriterSink(w io.Writer) RecordingSink {
+	return &writerSink{w: w}
+}
+
+// NewMemorySink returns a new in-memory recording sink
+func NewMemorySink() *MemorySink {
+	return new(MemorySink)
+}
+
+// Record stores the recording
+func (s *MemorySink) Record(ctx context.Context, rec *Recording) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.recordings = append(s.recordings, rec)
+	return nil
+}
+
+// Recordings returns the stored recordings
+func (s *MemorySink) Recordings() []*Recording {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]*Recording(nil), s.recordings...)
+}
+
+// RedactHeaders returns a redaction rule that redacts the values of the specified headers
+// Header names are case-insensitive. Event and response cookies are redacted if either Cookie or Set-Cookie is specified.
+func RedactHeaders(names ...string) RedactionRule {
+	m := make(map[string]bool, len(names))
+	for _, n := range names {
+		m[strings.ToLower(n)] = true
+	}
+
+	redactCookies := m["cookie"] || m["set-cookie"]
+
+	return func(v map[string]interface{}) {
+		walkObjects(v, func(o map[string]interface{}) {
+			for k, hv := range o {
+				switch {
+				case k == "headers" || k == "multiValueHeaders":
+					if h, ok := hv.(map[string]interface{}); ok {
+						for hk := range h {
+							if m[strings.ToLower(hk)] {
+								h[hk] = redactValue(h[hk])
+							}
+						}
+					}
+				case k == "cookies" && redactCookies:
+					o[k] = redactValue(hv)
+				}
+			}
+		})
+	}
+}
+
+// RedactBody returns a redaction rule that redacts request and response bodies
+func RedactBody() RedactionRule {
+	return func(v map[string]interface{}) {
+		walkObjects(v, func(o map[string]interface{}) {
+			switch b := o["body"].(type) {
+			case string:
+				o["body"] = RedactedValue
+				for _, k := range []string{"isBase64Encoded", "is_base64_encoded"} {
+					if _, ok := o[k]; ok {
+						o[k] = false
+					}
+				}
+				if _, ok := o["bodyEncoding"]; ok {
+					o["bodyEncoding"] = "text"
+				}
+			case map[string]interface{}:
+				if _, ok := b["data"]; ok {
+					b["data"], b["encoding"] = RedactedValue, "text"
+				}
+			}
+		})
+	}
+}
+
+func (s *writerSink) Record(ctx context.Context, rec *Recording) error {
+	b, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, err = s.w.Write(append(b, '\n'))
+	return err
+}
+
+// record records the invocation, logging any sink errors rather than failing the invocation
+func (r *recorder) record(ctx context.Context, payload, response []byte, err error) {
+	rec := &Recording{
+		Time:     time.Now().UTC(),
+		Payload:  r.redact(payload),
+		Response: r.redact(response),
+	}
+
+	if lc, ok := lambdacontext.FromContext(ctx); ok {
+		rec.RequestID = lc.AwsRequestID
+	}
+
+	if err != nil {
+		rec.Error = err.Error()
+	}
+
+	if err = r.sink.Record(ctx, rec); err != nil {
+		log.Printf("chop: failed to record invocation %s: %v", rec.RequestID, err)
+	}
+}
+
+// redact applies the redaction rules to the JSON value, returning the value unchanged if it is not a JSON object
+// Invalid JSON values are recorded as strings
+func (r *recorder) redact(b []byte) json.RawMessage {
+	if len(b) == 0 {
+		return nil
+	}
+
+	if !json.Valid(b) {
+		s, _ := json.Marshal(string(b))
+		return s
+	}
+
+	if len(r.rules) == 0 {
+		return append(json.RawMessage(nil), b...)
+	}
+
+	d := json.NewDecoder(bytes.NewReader(b))
+	d.UseNumber()
+
+	var v map[string]interface{}
+	if err := d.Decode(&v); err != nil {
+		return append(json.RawMessage(nil), b...)
+	}
+
+	for _, rule := range r.rules {
+		rule(v)
+	}
+
+	rb, err := json.Marshal(v)
+	if err != nil {
+		return append(json.RawMessage(nil), b...)
+	}
+
+	return rb
+}
+
+func walkObjects(v interface{}, fn func(map[string]interface{})) {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		fn(t)
+		for _, cv := range t {
+			walkObjects(cv, fn)
+		}
+	case []interface{}:
+		for _, cv := range t {
+			walkObjects(cv, fn)
+		}
+	}
+}
+
+// redactValue redacts string values, multi-value arrays and CloudFront header values
+func redactValue(v interface{}) interface{} {
+	switch t := v.(type) {
+	case string:
+		return RedactedValue
+	case []interface{}:
+		for i, e := range t {
+			if h, ok := e.(map[string]interface{}); ok {
+				h["value"] = RedactedValue
+				continue
+			}
+
+			t[i] = redactValue(e)
+		}
+	}
+
+	return v
+}
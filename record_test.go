@@ -0,0 +1,174 @@
+package chop_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/lambdacontext"
+	"github.com/tidwall/gjson"
+
+	"github.com/stevecallear/chop/v2"
+)
+
+func TestWithRecorder(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: "secret"})
+		w.Header().Set("X-Token", "secret")
+		w.Write([]byte("response"))
+	})
+
+	const cookiePayload = `{
+	"version": "2.0",
+	"rawPath": "/",
+	"cookies": ["session=secret"],
+	"headers": {"authorization": "secret", "x-custom-header": "v1"},
+	"requestContext": {"apiId": "apiid", "timeEpoch": 1583348638390, "http": {"method": "POST"}},
+	"body": "c2VjcmV0",
+	"isBase64Encoded": true
+}`
+
+	const cloudFrontPayload = `{
+	"Records": [{"cf": {
+		"config": {"distributionDomainName": "d111111abcdef8.cloudfront.net"},
+		"request": {
+			"method": "POST",
+			"uri": "/",
+			"headers": {"authorization": [{"key": "Authorization", "value": "secret"}]},
+			"body": {"action": "read-only", "data": "c2VjcmV0", "encoding": "base64"}
+		}
+	}}]
+}`
+
+	tests := []struct {
+		name     string
+		payload  string
+		rules    []chop.RedactionRule
+		assertFn func(*testing.T, *chop.Recording)
+	}{
+		{
+			name:    "should record payloads and responses",
+			payload: apiGatewayV2HTTPEventPayload,
+			assertFn: func(t *testing.T, rec *chop.Recording) {
+				assertDeepEqual(t, gjson.GetBytes(rec.Payload, "rawPath").String(), "/resource/")
+				assertDeepEqual(t, gjson.GetBytes(rec.Response, "statusCode").Int(), int64(200))
+				assertDeepEqual(t, gjson.GetBytes(rec.Response, "body").String(), "response")
+				assertDeepEqual(t, rec.RequestID, "id")
+				assertDeepEqual(t, rec.Error, "")
+				assertDeepEqual(t, rec.Time.IsZero(), false)
+			},
+		},
+		{
+			name:    "should record errors",
+			payload: `{}`,
+			assertFn: func(t *testing.T, rec *chop.Recording) {
+				assertDeepEqual(t, string(rec.Payload), `{}`)
+				assertDeepEqual(t, rec.Response, json.RawMessage(nil))
+				assertDeepEqual(t, rec.Error, chop.ErrUnsupportedEventType.Error())
+			},
+		},
+		{
+			name:    "should record invalid payloads as strings",
+			payload: `invalid`,
+			assertFn: func(t *testing.T, rec *chop.Recording) {
+				assertDeepEqual(t, string(rec.Payload), `"invalid"`)
+			},
+		},
+		{
+			name:    "should redact headers",
+			payload: cookiePayload,
+			rules:   []chop.RedactionRule{chop.RedactHeaders("Authorization", "X-Token", "Set-Cookie")},
+			assertFn: func(t *testing.T, rec *chop.Recording) {
+				assertDeepEqual(t, gjson.GetBytes(rec.Payload, "headers.authorization").String(), chop.RedactedValue)
+				assertDeepEqual(t, gjson.GetBytes(rec.Payload, "headers.x-custom-header").String(), "v1")
+				assertDeepEqual(t, gjson.GetBytes(rec.Payload, "cookies.0").String(), chop.RedactedValue)
+				assertDeepEqual(t, gjson.GetBytes(rec.Payload, "body").String(), "c2VjcmV0")
+				assertDeepEqual(t, gjson.GetBytes(rec.Payload, "requestContext.timeEpoch").Raw, "1583348638390")
+				assertDeepEqual(t, gjson.GetBytes(rec.Response, "headers.X-Token").String(), chop.RedactedValue)
+				assertDeepEqual(t, gjson.GetBytes(rec.Response, "multiValueHeaders.X-Token.0").String(), chop.RedactedValue)
+				assertDeepEqual(t, gjson.GetBytes(rec.Response, "cookies.0").String(), chop.RedactedValue)
+			},
+		},
+		{
+			name:    "should redact bodies",
+			payload: cookiePayload,
+			rules:   []chop.RedactionRule{chop.RedactBody()},
+			assertFn: func(t *testing.T, rec *chop.Recording) {
+				assertDeepEqual(t, gjson.GetBytes(rec.Payload, "body").String(), chop.RedactedValue)
+				assertDeepEqual(t, gjson.GetBytes(rec.Payload, "isBase64Encoded").Bool(), false)
+				assertDeepEqual(t, gjson.GetBytes(rec.Payload, "headers.authorization").String(), "secret")
+				assertDeepEqual(t, gjson.GetBytes(rec.Response, "body").String(), chop.RedactedValue)
+			},
+		},
+		{
+			name:    "should redact cloudfront headers and bodies",
+			payload: cloudFrontPayload,
+			rules:   []chop.RedactionRule{chop.RedactHeaders("authorization"), chop.RedactBody()},
+			assertFn: func(t *testing.T, rec *chop.Recording) {
+				r := gjson.GetBytes(rec.Payload, "Records.0.cf.request")
+				assertDeepEqual(t, r.Get("headers.authorization.0.key").String(), "Authorization")
+				assertDeepEqual(t, r.Get("headers.authorization.0.value").String(), chop.RedactedValue)
+				assertDeepEqual(t, r.Get("body.data").String(), chop.RedactedValue)
+				assertDeepEqual(t, r.Get("body.encoding").String(), "text")
+				assertDeepEqual(t, gjson.GetBytes(rec.Response, "body").String(), chop.RedactedValue)
+				assertDeepEqual(t, gjson.GetBytes(rec.Response, "bodyEncoding").String(), "text")
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sink := chop.NewMemorySink()
+			ctx := lambdacontext.NewContext(context.Background(), &lambdacontext.LambdaContext{AwsRequestID: "id"})
+
+			chop.Wrap(handler, chop.WithRecorder(sink, tt.rules...)).Invoke(ctx, []byte(tt.payload))
+
+			recs := sink.Recordings()
+			assertDeepEqual(t, len(recs), 1)
+			if len(recs) == 1 {
+				tt.assertFn(t, recs[0])
+			}
+		})
+	}
+}
+
+func TestNewWriterSink(t *testing.T) {
+	t.Run("should write newline delimited json", func(t *testing.T) {
+		b := new(bytes.Buffer)
+		h := chop.Wrap(http.NotFoundHandler(), chop.WithRecorder(chop.NewWriterSink(b)))
+
+		for i := 0; i < 2; i++ {
+			h.Invoke(context.Background(), []byte(apiGatewayV2HTTPEventPayload))
+		}
+
+		ls := strings.Split(strings.TrimSpace(b.String()), "\n")
+		assertDeepEqual(t, len(ls), 2)
+
+		for _, l := range ls {
+			rec := new(chop.Recording)
+			err := json.Unmarshal([]byte(l), rec)
+			assertErrorExists(t, err, false)
+			assertDeepEqual(t, gjson.GetBytes(rec.Response, "statusCode").Int(), int64(404))
+		}
+	})
+}
+
+func TestWithRecorder_SinkError(t *testing.T) {
+	t.Run("should log sink errors", func(t *testing.T) {
+		buf := captureLog(t)
+
+		sink := chop.RecordingSinkFunc(func(context.Context, *chop.Recording) error {
+			return errors.New("error")
+		})
+
+		h := chop.Wrap(http.NotFoundHandler(), chop.WithRecorder(sink))
+		_, err := h.Invoke(context.Background(), []byte(apiGatewayV2HTTPEventPayload))
+
+		assertErrorExists(t, err, false)
+		assertDeepEqual(t, strings.Contains(buf.String(), "failed to record invocation"), true)
+	})
+}
@@ -0,0 +1,57 @@
+package chop
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/aws/aws-lambda-go/lambdacontext"
+)
+
+// PanicHandler writes the response for a request that caused the wrapped handler to panic
+type PanicHandler func(w http.ResponseWriter, r *http.Request, v interface{})
+
+// DefaultPanicHandler writes a plain text internal server error response
+func DefaultPanicHandler(w http.ResponseWriter, r *http.Request, v interface{}) {
+	http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+}
+
+// serveHTTP serves the request, recovering from any panic in the wrapped handler
+// An error is returned if the handler panics after a streamed response has been committed
+func (h *Handler) serveHTTP(w *ResponseWriter, r *http.Request) (err error) {
+	defer func() {
+		v := recover()
+		if v == nil {
+			return
+		}
+
+		if h.repanic {
+			panic(v)
+		}
+
+		var id string
+		if lc, ok := lambdacontext.FromContext(r.Context()); ok {
+			id = lc.AwsRequestID
+		}
+
+		log.Printf("chop: panic serving request %s: %v\n%s", id, v, debug.Stack())
+
+		if w.committed {
+			err = fmt.Errorf("chop: panic serving request: %v", v)
+			return
+		}
+
+		ph := h.panicHandler
+		if ph == nil {
+			ph = DefaultPanicHandler
+		}
+
+		w.reset()
+		ph(w, r, v)
+	}()
+
+	h.ServeHTTP(w, withResponseWriter(r, w))
+
+	return nil
+}
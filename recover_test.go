@@ -0,0 +1,98 @@
+package chop_test
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/lambdacontext"
+	"github.com/tidwall/gjson"
+
+	"github.com/stevecallear/chop/v2"
+)
+
+func TestDefaultPanicHandler(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload string
+	}{
+		{
+			name:    "should recover api gateway proxy events",
+			payload: apiGatewayProxyEventPayload,
+		},
+		{
+			name:    "should recover api gateway http v2 events",
+			payload: apiGatewayV2HTTPEventPayload,
+		},
+		{
+			name:    "should recover lambda function url events",
+			payload: lambdaFunctionURLEventPayload,
+		},
+		{
+			name:    "should recover alb target group events",
+			payload: albTargetGroupSingleValueEventPayload,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := captureLog(t)
+
+			h := chop.Wrap(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("X-Custom-Header", "v1")
+				w.WriteHeader(http.StatusCreated)
+				w.Write([]byte("body"))
+				panic("error")
+			}))
+
+			ctx := lambdacontext.NewContext(context.Background(), &lambdacontext.LambdaContext{AwsRequestID: "requestid"})
+
+			b, err := h.Invoke(ctx, []byte(tt.payload))
+			assertErrorExists(t, err, false)
+
+			act := gjson.GetManyBytes(b, "statusCode", "body", "headers.X-Custom-Header")
+			assertDeepEqual(t, act[0].Int(), int64(http.StatusInternalServerError))
+			assertDeepEqual(t, act[1].String(), "Internal Server Error\n")
+			assertDeepEqual(t, act[2].Exists(), false)
+
+			l := buf.String()
+			if !strings.Contains(l, "panic serving request requestid: error") {
+				t.Errorf("got %s, expected panic log", l)
+			}
+		})
+	}
+}
+
+func TestWithPanicHandler(t *testing.T) {
+	captureLog(t)
+
+	h := chop.Wrap(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("error")
+	}), chop.WithPanicHandler(func(w http.ResponseWriter, r *http.Request, v interface{}) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusServiceUnavailable)
+		w.Write([]byte(`{"error":"` + v.(string) + `"}`))
+	}))
+
+	b, err := h.Invoke(context.Background(), []byte(apiGatewayProxyEventPayload))
+	assertErrorExists(t, err, false)
+
+	act := gjson.GetManyBytes(b, "statusCode", "body", "headers.Content-Type")
+	assertDeepEqual(t, act[0].Int(), int64(http.StatusServiceUnavailable))
+	assertDeepEqual(t, act[1].String(), `{"error":"error"}`)
+	assertDeepEqual(t, act[2].String(), "application/json")
+}
+
+func TestWithRepanic(t *testing.T) {
+	h := chop.Wrap(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("error")
+	}), chop.WithRepanic())
+
+	defer func() {
+		assertDeepEqual(t, recover(), "error")
+	}()
+
+	h.Invoke(context.Background(), []byte(apiGatewayProxyEventPayload))
+	t.Error("got nil, expected panic")
+}
@@ -0,0 +1,46 @@
+package chop
+
+import (
+	"context"
+	"net/http"
+	"strings"
+)
+
+type (
+	// Route represents the API gateway route that matched a request
+	Route struct {
+		// Template is the route template, e.g. /users/{id}
+		Template string
+
+		// Parameters are the path parameters extracted from the request path using the template
+		Parameters map[string]string
+	}
+
+	routeContextKey struct{}
+)
+
+// WithRoute returns a copy of the request with the specified route stored in the request context
+// On Go 1.22 and later the route parameters are also set as request path values
+func WithRoute(r *http.Request, rt Route) *http.Request {
+	ctx := context.WithValue(r.Context(), routeContextKey{}, rt)
+
+	r = r.WithContext(ctx)
+	setPathValues(r, rt.Parameters)
+
+	return r
+}
+
+// GetRoute returns the route stored within the specified request context if it exists
+func GetRoute(r *http.Request) (Route, bool) {
+	rt, ok := r.Context().Value(routeContextKey{}).(Route)
+	return rt, ok
+}
+
+// routeTemplate returns the template of the specified route key, e.g. GET /users/{id}
+func routeTemplate(routeKey string) string {
+	if i := strings.IndexByte(routeKey, ' '); i >= 0 {
+		return routeKey[i+1:]
+	}
+
+	return routeKey
+}
@@ -0,0 +1,11 @@
+//go:build go1.22
+
+package chop
+
+import "net/http"
+
+func setPathValues(r *http.Request, params map[string]string) {
+	for k, v := range params {
+		r.SetPathValue(k, v)
+	}
+}
@@ -0,0 +1,67 @@
+//go:build go1.22
+
+//go:debug httpmuxgo121=0
+
+package chop_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stevecallear/chop/v2"
+)
+
+func TestWithRoute_PathValues(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler func(*string) http.Handler
+		payload string
+		exp     string
+	}{
+		{
+			name: "should set path values",
+			handler: func(act *string) http.Handler {
+				return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					*act = r.PathValue("id")
+				})
+			},
+			payload: `{"httpMethod":"GET","path":"/users/1","resource":"/users/{id}","pathParameters":{"id":"1"},"requestContext":{"apiId":"id"}}`,
+			exp:     "1",
+		},
+		{
+			name: "should set greedy path values",
+			handler: func(act *string) http.Handler {
+				return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					*act = r.PathValue("proxy")
+				})
+			},
+			payload: `{"version":"2.0","rawPath":"/users/1","routeKey":"ANY /{proxy+}","pathParameters":{"proxy":"users/1"},"requestContext":{"apiId":"id","http":{"method":"GET"}}}`,
+			exp:     "users/1",
+		},
+		{
+			name: "should support serve mux routing",
+			handler: func(act *string) http.Handler {
+				mux := http.NewServeMux()
+				mux.HandleFunc("GET /users/{user}", func(w http.ResponseWriter, r *http.Request) {
+					*act = r.PathValue("user")
+				})
+
+				return mux
+			},
+			payload: `{"httpMethod":"GET","path":"/users/1","resource":"/{proxy+}","pathParameters":{"proxy":"users/1"},"requestContext":{"apiId":"id"}}`,
+			exp:     "1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var act string
+			h := chop.Wrap(tt.handler(&act))
+
+			_, err := h.Invoke(context.Background(), []byte(tt.payload))
+			assertErrorExists(t, err, false)
+			assertDeepEqual(t, act, tt.exp)
+		})
+	}
+}
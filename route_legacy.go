@@ -0,0 +1,8 @@
+//go:build !go1.22
+
+package chop
+
+import "net/http"
+
+// setPathValues is a no-op prior to Go 1.22, which introduced request path values
+func setPathValues(r *http.Request, params map[string]string) {}
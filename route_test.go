@@ -0,0 +1,65 @@
+package chop_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stevecallear/chop/v2"
+)
+
+func TestGetRoute(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload string
+		ok      bool
+		exp     chop.Route
+	}{
+		{
+			name:    "should return api gateway proxy routes",
+			payload: `{"httpMethod":"GET","path":"/users/1","resource":"/users/{id}","pathParameters":{"id":"1"},"requestContext":{"apiId":"id"}}`,
+			ok:      true,
+			exp: chop.Route{
+				Template:   "/users/{id}",
+				Parameters: map[string]string{"id": "1"},
+			},
+		},
+		{
+			name:    "should return api gateway http v2 routes",
+			payload: `{"version":"2.0","rawPath":"/users/1","routeKey":"GET /users/{id}","pathParameters":{"id":"1"},"requestContext":{"apiId":"id","http":{"method":"GET"}}}`,
+			ok:      true,
+			exp: chop.Route{
+				Template:   "/users/{id}",
+				Parameters: map[string]string{"id": "1"},
+			},
+		},
+		{
+			name:    "should return api gateway http v2 default routes",
+			payload: `{"version":"2.0","rawPath":"/users/1","routeKey":"$default","requestContext":{"apiId":"id","http":{"method":"GET"}}}`,
+			ok:      true,
+			exp: chop.Route{
+				Template: "$default",
+			},
+		},
+		{
+			name:    "should not return routes for other event types",
+			payload: `{"httpMethod":"GET","path":"/users/1","requestContext":{"elb":{}}}`,
+			ok:      false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var act chop.Route
+			var ok bool
+			h := chop.Wrap(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				act, ok = chop.GetRoute(r)
+			}))
+
+			_, err := h.Invoke(context.Background(), []byte(tt.payload))
+			assertErrorExists(t, err, false)
+			assertDeepEqual(t, ok, tt.ok)
+			assertDeepEqual(t, act, tt.exp)
+		})
+	}
+}
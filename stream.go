@@ -0,0 +1,123 @@
+package chop
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+type (
+	// StreamingEventProcessor represents a lambda event processor that supports response streaming
+	StreamingEventProcessor interface {
+		EventProcessor
+
+		// MarshalResponsePrelude marshals the response status and headers that precede the streamed body
+		MarshalResponsePrelude(w *ResponseWriter) ([]byte, error)
+	}
+
+	streamingEventProcessor struct {
+		*eventProcessor
+		marshalResponsePrelude func(*ResponseWriter) ([]byte, error)
+	}
+
+	streamingResponse struct {
+		reader io.Reader
+	}
+)
+
+const streamingContentType = "application/vnd.awslambda.http-integration-response"
+
+var streamingPreludeDelimiter = make([]byte, 8)
+
+// StartStreaming wraps and starts the specified HTTP handler as a lambda function handler with response streaming
+// Responses are streamed for function URLs with the RESPONSE_STREAM invoke mode, which requires the provided runtime
+func StartStreaming(h http.Handler, opts ...Option) {
+	w := Wrap(h, opts...)
+	if addr, ok := w.isLocal(); ok {
+		log.Fatal(w.listenLocal(addr))
+	}
+
+	lambda.StartWithOptions(func(ctx context.Context, payload json.RawMessage) (io.Reader, error) {
+		return w.InvokeStream(ctx, payload)
+	}, w.lambdaOptions...)
+}
+
+// InvokeStream invokes the lambda function handler, streaming the response if the event type supports it
+// Flushing the response writer writes the response status and headers, with subsequent writes passed
+// directly to the returned reader
+func (h *Handler) InvokeStream(ctx context.Context, payload []byte) (io.Reader, error) {
+	p, _ := h.getEventProcessor(payload)
+
+	sp, ok := p.(StreamingEventProcessor)
+	if !ok {
+		b, err := h.Invoke(ctx, payload)
+		if err != nil {
+			return nil, err
+		}
+
+		return bytes.NewReader(b), nil
+	}
+
+	r, err := h.unmarshalRequest(ctx, sp, payload)
+	if err != nil {
+		b := new(bytes.Buffer)
+		w := newStreamingResponseWriter(b, sp)
+
+		if err = h.handleError(w, err, sp); err != nil {
+			return nil, err
+		}
+
+		if err = w.commit(); err != nil {
+			return nil, err
+		}
+
+		return &streamingResponse{reader: b}, nil
+	}
+
+	pr, pw := io.Pipe()
+	w := newStreamingResponseWriter(pw, sp)
+
+	go func() {
+		err := h.serveHTTP(w, r)
+		if err == nil {
+			err = w.commit()
+		}
+
+		pw.CloseWithError(err)
+	}()
+
+	return &streamingResponse{reader: pr}, nil
+}
+
+func (p *streamingEventProcessor) MarshalResponsePrelude(w *ResponseWriter) ([]byte, error) {
+	return p.marshalResponsePrelude(w)
+}
+
+func (r *streamingResponse) Read(b []byte) (int, error) {
+	return r.reader.Read(b)
+}
+
+func (r *streamingResponse) ContentType() string {
+	return streamingContentType
+}
+
+func (r *streamingResponse) Close() error {
+	if c, ok := r.reader.(io.Closer); ok {
+		return c.Close()
+	}
+
+	return nil
+}
+
+func newStreamingResponseWriter(stream io.Writer, p StreamingEventProcessor) *ResponseWriter {
+	w := NewResponseWriter()
+	w.stream = stream
+	w.prelude = p.MarshalResponsePrelude
+
+	return w
+}
@@ -0,0 +1,184 @@
+package chop_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stevecallear/chop/v2"
+)
+
+func TestStartStreaming(t *testing.T) {
+	flushed := make(chan struct{})
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Write([]byte("a"))
+		w.(http.Flusher).Flush()
+
+		select {
+		case <-flushed:
+		case <-time.After(time.Second):
+			t.Error("got timeout, expected flushed response")
+		}
+
+		w.Write([]byte("b"))
+	})
+
+	type result struct {
+		contentType string
+		body        []byte
+	}
+
+	results := make(chan result, 1)
+	startRuntimeAPI(t, lambdaFunctionURLEventPayload, func(r *http.Request) {
+		prefix := append([]byte(lambdaFunctionURLStreamingPrelude), make([]byte, 9)...)
+		prefix[len(prefix)-1] = 'a'
+
+		b := make([]byte, len(prefix))
+		if _, err := io.ReadFull(r.Body, b); err != nil {
+			t.Errorf("got %v, expected nil", err)
+		}
+		close(flushed)
+
+		rest, _ := io.ReadAll(r.Body)
+		results <- result{
+			contentType: r.Header.Get("Content-Type"),
+			body:        append(b, rest...),
+		}
+	})
+
+	go chop.StartStreaming(handler)
+
+	select {
+	case act := <-results:
+		assertDeepEqual(t, act.contentType, "application/vnd.awslambda.http-integration-response")
+		assertDeepEqual(t, string(act.body), lambdaFunctionURLStreamingPrelude+"\x00\x00\x00\x00\x00\x00\x00\x00ab")
+	case <-time.After(5 * time.Second):
+		t.Error("got timeout, expected response")
+	}
+}
+
+func TestHandler_InvokeStream(t *testing.T) {
+	tests := []struct {
+		name      string
+		handlerFn http.HandlerFunc
+		payload   string
+		err       bool
+		readErr   bool
+		exp       string
+	}{
+		{
+			name:      "should return an error if the event is invalid",
+			payload:   `{}`,
+			handlerFn: func(http.ResponseWriter, *http.Request) {},
+			err:       true,
+		},
+		{
+			name:      "should return an error if the buffered event is invalid",
+			payload:   `{"httpMethod":"GET","path":"/resource###%","requestContext":{"apiId":"id"}}`,
+			handlerFn: func(http.ResponseWriter, *http.Request) {},
+			err:       true,
+		},
+		{
+			name:      "should return an error if the streamed event is invalid",
+			payload:   `{"version":"2.0","rawPath":"/resource###%","requestContext":{"domainName":"id.lambda-url.eu-west-1.on.aws"}}`,
+			handlerFn: func(http.ResponseWriter, *http.Request) {},
+			err:       true,
+		},
+		{
+			name:    "should buffer responses for event types that do not support streaming",
+			payload: apiGatewayProxyEventPayload,
+			handlerFn: func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte("a"))
+				w.(http.Flusher).Flush()
+				w.Write([]byte("b"))
+			},
+			exp: `{"statusCode":200,"headers":{"Content-Type":"text/plain; charset=utf-8"},"multiValueHeaders":{"Content-Type":["text/plain; charset=utf-8"]},"body":"ab"}`,
+		},
+		{
+			name:    "should stream function url responses",
+			payload: lambdaFunctionURLEventPayload,
+			handlerFn: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "text/event-stream")
+				w.Write([]byte("a"))
+				w.(http.Flusher).Flush()
+				w.Write([]byte("b"))
+			},
+			exp: lambdaFunctionURLStreamingPrelude + "\x00\x00\x00\x00\x00\x00\x00\x00ab",
+		},
+		{
+			name:    "should stream function url cookies",
+			payload: lambdaFunctionURLEventPayload,
+			handlerFn: func(w http.ResponseWriter, r *http.Request) {
+				http.SetCookie(w, &http.Cookie{Name: "c1", Value: "v1"})
+				w.Header().Set("Content-Type", "text/event-stream")
+				w.Write([]byte("a"))
+			},
+			exp: `{"statusCode":200,"headers":{"Content-Type":"text/event-stream"},"cookies":["c1=v1"]}` + "\x00\x00\x00\x00\x00\x00\x00\x00a",
+		},
+		{
+			name:    "should write the prelude on flush",
+			payload: lambdaFunctionURLEventPayload,
+			handlerFn: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "text/event-stream")
+				w.(http.Flusher).Flush()
+				w.WriteHeader(http.StatusCreated)
+			},
+			exp: lambdaFunctionURLStreamingPrelude + "\x00\x00\x00\x00\x00\x00\x00\x00",
+		},
+		{
+			name:    "should write the prelude if the body is empty",
+			payload: lambdaFunctionURLEventPayload,
+			handlerFn: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "text/event-stream")
+			},
+			exp: lambdaFunctionURLStreamingPrelude + "\x00\x00\x00\x00\x00\x00\x00\x00",
+		},
+		{
+			name:    "should write the panic response if the handler panics before the response is committed",
+			payload: lambdaFunctionURLEventPayload,
+			handlerFn: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "text/event-stream")
+				panic(errors.New("error"))
+			},
+			exp: `{"statusCode":500,"headers":{"Content-Type":"text/plain; charset=utf-8","X-Content-Type-Options":"nosniff"},"cookies":[]}` +
+				"\x00\x00\x00\x00\x00\x00\x00\x00Internal Server Error\n",
+		},
+		{
+			name:    "should return a read error if the handler panics after the response is committed",
+			payload: lambdaFunctionURLEventPayload,
+			handlerFn: func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte("a"))
+				panic(errors.New("error"))
+			},
+			readErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			captureLog(t)
+
+			r, err := chop.Wrap(tt.handlerFn).InvokeStream(context.Background(), []byte(tt.payload))
+			assertErrorExists(t, err, tt.err)
+			if err != nil {
+				return
+			}
+
+			b := new(bytes.Buffer)
+			_, err = b.ReadFrom(r)
+			assertErrorExists(t, err, tt.readErr)
+			if err != nil {
+				return
+			}
+
+			assertDeepEqual(t, b.String(), tt.exp)
+		})
+	}
+}
+
+const lambdaFunctionURLStreamingPrelude = `{"statusCode":200,"headers":{"Content-Type":"text/event-stream"},"cookies":[]}`
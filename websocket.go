@@ -0,0 +1,54 @@
+package chop
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+type (
+	// WebSocketConnection represents the API gateway websocket connection that sent a request
+	WebSocketConnection struct {
+		// ID is the connection ID, used to post messages to the connection
+		ID string
+
+		// EventType is the websocket event type, e.g. CONNECT, MESSAGE or DISCONNECT
+		EventType string
+
+		// Endpoint is the connections API endpoint for the websocket API stage
+		Endpoint string
+
+		// ConnectedAt is the time that the connection was established
+		ConnectedAt time.Time
+	}
+
+	webSocketConnectionContextKey struct{}
+)
+
+// Websocket event types
+const (
+	WebSocketEventConnect    = "CONNECT"
+	WebSocketEventMessage    = "MESSAGE"
+	WebSocketEventDisconnect = "DISCONNECT"
+)
+
+// WithWebSocketConnection returns a copy of the request with the specified connection stored in the request context
+func WithWebSocketConnection(r *http.Request, c WebSocketConnection) *http.Request {
+	ctx := context.WithValue(r.Context(), webSocketConnectionContextKey{}, c)
+
+	return r.WithContext(ctx)
+}
+
+// GetWebSocketConnection returns the websocket connection stored within the specified request context if it exists
+func GetWebSocketConnection(r *http.Request) (WebSocketConnection, bool) {
+	c, ok := r.Context().Value(webSocketConnectionContextKey{}).(WebSocketConnection)
+	return c, ok
+}
+
+func connectionEndpoint(domainName, stage string) string {
+	if domainName == "" {
+		return ""
+	}
+
+	return "https://" + domainName + "/" + stage
+}
@@ -0,0 +1,120 @@
+package chop_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stevecallear/chop/v2"
+)
+
+func TestHandler_Invoke_WebSocket(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload string
+		handler http.HandlerFunc
+		exp     request
+		conn    chop.WebSocketConnection
+		res     *events.APIGatewayProxyResponse
+	}{
+		{
+			name:    "should handle connect events",
+			payload: `{"headers":{"Host":"ws.example.com","X-Forwarded-Proto":"https"},"multiValueHeaders":{"Host":["ws.example.com"],"X-Forwarded-Proto":["https"]},"queryStringParameters":{"token":"abc"},"multiValueQueryStringParameters":{"token":["abc"]},"requestContext":{"routeKey":"$connect","eventType":"CONNECT","connectionId":"L0SM9cOFvHcCIhw=","connectedAt":1583348638390,"domainName":"ws.example.com","stage":"dev","apiId":"apiid","identity":{"sourceIp":"192.0.2.1"}},"isBase64Encoded":false}`,
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			},
+			exp: request{
+				method: http.MethodPost,
+				url:    "/$connect?token=abc",
+				header: http.Header{"X-Forwarded-Proto": {"https"}},
+			},
+			conn: chop.WebSocketConnection{
+				ID:          "L0SM9cOFvHcCIhw=",
+				EventType:   chop.WebSocketEventConnect,
+				Endpoint:    "https://ws.example.com/dev",
+				ConnectedAt: time.Date(2020, 3, 4, 19, 3, 58, 390000000, time.UTC),
+			},
+			res: &events.APIGatewayProxyResponse{
+				StatusCode:        http.StatusOK,
+				Headers:           map[string]string{},
+				MultiValueHeaders: map[string][]string{},
+			},
+		},
+		{
+			name:    "should handle message events",
+			payload: `{"requestContext":{"routeKey":"sendMessage","eventType":"MESSAGE","connectionId":"id","domainName":"ws.example.com","stage":"dev","apiId":"apiid"},"body":"{\"action\":\"sendMessage\"}","isBase64Encoded":false}`,
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte("sent"))
+			},
+			exp: request{
+				method: http.MethodPost,
+				url:    "/sendMessage",
+				body:   `{"action":"sendMessage"}`,
+				header: http.Header{},
+			},
+			conn: chop.WebSocketConnection{
+				ID:        "id",
+				EventType: chop.WebSocketEventMessage,
+				Endpoint:  "https://ws.example.com/dev",
+			},
+			res: &events.APIGatewayProxyResponse{
+				StatusCode:        http.StatusOK,
+				Headers:           map[string]string{"Content-Type": "text/plain; charset=utf-8"},
+				MultiValueHeaders: map[string][]string{"Content-Type": {"text/plain; charset=utf-8"}},
+				Body:              "sent",
+			},
+		},
+		{
+			name:    "should handle disconnect events",
+			payload: `{"requestContext":{"routeKey":"$disconnect","eventType":"DISCONNECT","connectionId":"id","domainName":"ws.example.com","stage":"dev","apiId":"apiid"},"isBase64Encoded":false}`,
+			handler: func(w http.ResponseWriter, r *http.Request) {},
+			exp: request{
+				method: http.MethodPost,
+				url:    "/$disconnect",
+				header: http.Header{},
+			},
+			conn: chop.WebSocketConnection{
+				ID:        "id",
+				EventType: chop.WebSocketEventDisconnect,
+				Endpoint:  "https://ws.example.com/dev",
+			},
+			res: &events.APIGatewayProxyResponse{
+				StatusCode:        http.StatusOK,
+				Headers:           map[string]string{},
+				MultiValueHeaders: map[string][]string{},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var req request
+			var conn chop.WebSocketConnection
+			var info chop.RequestInfo
+			var event *events.APIGatewayWebsocketProxyRequest
+
+			h := chop.Wrap(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				req = toRequest(r)
+				conn, _ = chop.GetWebSocketConnection(r)
+				info, _ = chop.GetRequestInfo(r)
+				event, _ = chop.GetEventAs[*events.APIGatewayWebsocketProxyRequest](r)
+				tt.handler(w, r)
+			}))
+
+			b, err := h.Invoke(context.Background(), []byte(tt.payload))
+			assertErrorExists(t, err, false)
+			assertDeepEqual(t, req, tt.exp)
+			assertDeepEqual(t, conn, tt.conn)
+			assertDeepEqual(t, info.Kind, chop.KindAPIGatewayWebsocket)
+			assertDeepEqual(t, event.RequestContext.ConnectionID, tt.conn.ID)
+
+			res := new(events.APIGatewayProxyResponse)
+			err = json.Unmarshal(b, res)
+			assertErrorExists(t, err, false)
+			assertDeepEqual(t, res, tt.res)
+		})
+	}
+}